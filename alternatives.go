@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// alternativePlans returns up to k plans: best, the plan the run uses, first,
+// then the best of the others, with their turns estimated under rules
+func alternativePlans(farm *lemin.Farm, best PathCombination, k int, rules lemin.Rules, ties ...TieBreaker) []PathCombination {
+	if k <= 0 {
+		return nil
+	}
+
+	plans := []PathCombination{best}
+	for _, combo := range FindTopPathCombinations(farm.AntCount(), FindAllPaths(farm), k, rules, ties...) {
+		if len(plans) == k {
+			break
+		}
+		if !samePaths(combo.Paths, best.Paths) {
+			plans = append(plans, combo)
+		}
+	}
+	return plans
+}
+
+// samePaths reports whether two combinations use the same paths in the same order
+func samePaths(a, b [][]*lemin.Room) bool {
+	return slices.EqualFunc(a, b, func(x, y []*lemin.Room) bool { return slices.Equal(x, y) })
+}
+
+// WriteAlternatives writes the combinations side by side, one column each:
+// their turns, total moves and paths with the ants each one carries. The
+// first one is the plan the run uses, and turns are counted under rules.
+func WriteAlternatives(w io.Writer, antCount int, rules lemin.Rules, combos []PathCombination) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+
+	fmt.Fprintf(tw, "Alternatives: the %d best plans for %d ants under %s rules, #1 is the one used\n\n",
+		len(combos), antCount, rules.Name)
+
+	rows := 0
+	fmt.Fprint(tw, "\t")
+	for i, combo := range combos {
+		fmt.Fprintf(tw, "#%d\t", i+1)
+		rows = max(rows, len(combo.Paths))
+	}
+	fmt.Fprint(tw, "\nTurns\t")
+	for _, combo := range combos {
+		fmt.Fprintf(tw, "%d\t", combo.Turns)
+	}
+	fmt.Fprint(tw, "\nMoves\t")
+	for _, combo := range combos {
+		fmt.Fprintf(tw, "%d\t", combo.Moves)
+	}
+	fmt.Fprintln(tw)
+
+	for row := 0; row < rows; row++ {
+		fmt.Fprintf(tw, "Path %d\t", row+1)
+		for _, combo := range combos {
+			if row < len(combo.Paths) {
+				ants := DistributeAnts(antCount, combo.Paths)
+				fmt.Fprintf(tw, "%s (%d)\t", roomNames(combo.Paths[row]), ants[row])
+			} else {
+				fmt.Fprint(tw, "\t")
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	fmt.Fprintln(tw)
+
+	return tw.Flush()
+}
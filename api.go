@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// solveRequest is the JSON body of POST /solve: either the farm as lem-in
+// text, or its rooms and links in the same form as a lemin.Solution
+type solveRequest struct {
+	Farm string `json:"farm"`
+	lemin.Solution
+}
+
+// verifyRequest is the JSON body of POST /verify
+type verifyRequest struct {
+	Farm  string     `json:"farm"`  // The farm as lem-in text
+	Moves [][]string `json:"moves"` // The moves of each turn
+}
+
+// verifyResponse is the result of POST /verify
+type verifyResponse struct {
+	Valid      bool              `json:"valid"`
+	Turns      int               `json:"turns"`
+	Violations []lemin.Violation `json:"violations"`
+}
+
+// solveLimits bounds the work the server takes on, 0 meaning no limit
+type solveLimits struct {
+	rooms  int // Most rooms in a farm
+	ants   int // Most ants in a farm
+	paths  int // Most paths from start to end
+	solves int // Most requests solved or verified at once
+}
+
+// defaultSolveLimits returns limits that keep one request from taking the server down
+func defaultSolveLimits() solveLimits {
+	return solveLimits{rooms: 10000, ants: 100000, paths: 100000, solves: runtime.NumCPU()}
+}
+
+// check rejects a farm with more rooms or ants than the limits allow
+func (l solveLimits) check(farm *lemin.Farm) error {
+	if l.rooms > 0 && farm.NumRooms() > l.rooms {
+		return fmt.Errorf("%w: %d rooms, at most %d", errFarmTooLarge, farm.NumRooms(), l.rooms)
+	}
+	if l.ants > 0 && farm.AntCount() > l.ants {
+		return fmt.Errorf("%w: %d ants, at most %d", errFarmTooLarge, farm.AntCount(), l.ants)
+	}
+	return nil
+}
+
+// newSolveSlots returns the channel that limits how many requests are
+// solved at once, nil when there is no limit
+func newSolveSlots(l solveLimits) chan struct{} {
+	if l.solves <= 0 {
+		return nil
+	}
+	return make(chan struct{}, l.solves)
+}
+
+// handleHealth answers GET /health
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleSolve answers POST /solve. The body is lem-in text, or JSON
+// (Content-Type: application/json) described by solveRequest.
+func handleSolve(cfg config, slots chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readBody(w, r, cfg)
+		if !ok {
+			return
+		}
+
+		lines := lemin.ParseInput(string(body))
+		if isJSON(r) {
+			var req solveRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				writeError(w, http.StatusBadRequest, "bad_request", fmt.Errorf("ERROR: invalid JSON: %w", err))
+				return
+			}
+			if req.Farm != "" {
+				lines = lemin.ParseInput(req.Farm)
+			} else {
+				lines = req.FarmLines()
+			}
+		}
+
+		solution, err := withTimeout(r.Context(), cfg, slots, func(ctx context.Context) (*lemin.Solution, error) {
+			return solveLines(ctx, lines, cfg)
+		})
+		if err != nil {
+			writeSolveError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, solution)
+	}
+}
+
+// handleVerify answers POST /verify. The body is a full lem-in transcript
+// (the farm, then the moves), or JSON described by verifyRequest.
+func handleVerify(cfg config, slots chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readBody(w, r, cfg)
+		if !ok {
+			return
+		}
+
+		farmLines, turns := lemin.SplitTranscript(lemin.ParseInput(string(body)))
+		if isJSON(r) {
+			var req verifyRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				writeError(w, http.StatusBadRequest, "bad_request", fmt.Errorf("ERROR: invalid JSON: %w", err))
+				return
+			}
+			farmLines, turns = lemin.ParseInput(req.Farm), req.Moves
+		}
+
+		result, err := withTimeout(r.Context(), cfg, slots, func(ctx context.Context) (*verifyResponse, error) {
+			farm, err := lemin.BuildFarmWithOptions(farmLines, cfg.parse)
+			if err != nil {
+				return nil, err
+			}
+			if err := cfg.limits.check(farm); err != nil {
+				return nil, err
+			}
+			violations, err := lemin.VerifyMovesWithRulesContext(ctx, farm, turns, cfg.rules)
+			if err != nil {
+				return nil, err
+			}
+			if violations == nil {
+				violations = []lemin.Violation{}
+			}
+			return &verifyResponse{Valid: len(violations) == 0, Turns: len(turns), Violations: violations}, nil
+		})
+		if err != nil {
+			writeSolveError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// readBody reads the request body up to the configured limit.
+// On failure it writes the error response and returns false.
+func readBody(w http.ResponseWriter, r *http.Request, cfg config) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, cfg.maxBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "too_large",
+				fmt.Errorf("ERROR: request body larger than %d bytes", cfg.maxBody))
+		} else {
+			writeError(w, http.StatusBadRequest, "bad_request", fmt.Errorf("ERROR: could not read request: %w", err))
+		}
+		return nil, false
+	}
+	return body, true
+}
+
+// isJSON reports whether the request body is declared as JSON
+func isJSON(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "application/json"
+}
+
+// Errors of requests the server won't finish
+var (
+	errTimeout      = errors.New("ERROR: timed out while solving the farm")
+	errBusy         = errors.New("ERROR: too many farms being solved, try again later")
+	errFarmTooLarge = errors.New("ERROR: farm too large to solve")
+)
+
+// withTimeout runs work with a context that ends after the configured timeout
+// or when the client goes away; work stops early and its error becomes
+// errTimeout. It first waits for one of the slots, so at most cap(slots)
+// requests are worked on at once.
+func withTimeout[T any](ctx context.Context, cfg config, slots chan struct{}, work func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.timeout)
+	defer cancel()
+
+	var zero T
+	if slots != nil {
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		case <-ctx.Done():
+			return zero, errBusy
+		}
+	}
+
+	value, err := work(ctx)
+	if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return zero, errTimeout
+	}
+	return value, err
+}
+
+// writeSolveError sends a timeout or a farm error
+func writeSolveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errTimeout):
+		writeError(w, http.StatusServiceUnavailable, "timeout", err)
+		return
+	case errors.Is(err, errBusy):
+		writeError(w, http.StatusServiceUnavailable, "busy", err)
+		return
+	case errors.Is(err, errFarmTooLarge), errors.Is(err, ErrTooManyPaths):
+		writeError(w, http.StatusRequestEntityTooLarge, "too_large", err)
+		return
+	}
+	if strings.HasPrefix(err.Error(), "ERROR: invalid data format") {
+		writeFarmError(w, err)
+		return
+	}
+	writeError(w, http.StatusBadRequest, "bad_request", err)
+}
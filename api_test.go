@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// postJSON sends body to the API and decodes the JSON answer into out
+func postJSON(t *testing.T, url, contentType, body string, out any) int {
+	t.Helper()
+
+	resp, err := http.Post(url, contentType, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	return resp.StatusCode
+}
+
+// TestAPI_Solve tests solving farms sent as text and as JSON
+func TestAPI_Solve(t *testing.T) {
+	server := httptest.NewServer(newServer("", defaultConfig()))
+	defer server.Close()
+
+	content, err := os.ReadFile("testdata/example.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var solution lemin.Solution
+	status := postJSON(t, server.URL+"/solve", "text/plain", string(content), &solution)
+	if status != http.StatusOK || solution.Turns != 4 || len(solution.Moves) != 4 || len(solution.Paths) != 2 {
+		t.Errorf("POST /solve (text) returned %d with %d turns and %d paths", status, solution.Turns, len(solution.Paths))
+	}
+
+	// The same farm as structured JSON
+	request, _ := json.Marshal(map[string]any{
+		"ants":  solution.Ants,
+		"start": solution.Start,
+		"end":   solution.End,
+		"rooms": solution.Rooms,
+		"links": solution.Links,
+	})
+	var fromJSON lemin.Solution
+	status = postJSON(t, server.URL+"/solve", "application/json", string(request), &fromJSON)
+	if status != http.StatusOK || fromJSON.Turns != solution.Turns {
+		t.Errorf("POST /solve (JSON) returned %d with %d turns", status, fromJSON.Turns)
+	}
+
+	// The farm as text inside JSON
+	request, _ = json.Marshal(map[string]string{"farm": string(content)})
+	var fromText lemin.Solution
+	status = postJSON(t, server.URL+"/solve", "application/json; charset=utf-8", string(request), &fromText)
+	if status != http.StatusOK || fromText.Turns != solution.Turns {
+		t.Errorf("POST /solve (JSON text) returned %d with %d turns", status, fromText.Turns)
+	}
+}
+
+// TestAPI_SolveErrors tests that errors are structured and mirror the parser
+func TestAPI_SolveErrors(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.maxBody = 64
+	server := httptest.NewServer(newServer("", cfg))
+	defer server.Close()
+
+	tests := []struct {
+		contentType string
+		body        string
+		status      int
+		code        string
+		message     string
+	}{
+		{"text/plain", "0\n", http.StatusUnprocessableEntity, "invalid_data_format", "ERROR: invalid data format, invalid number of ants"},
+		{"text/plain", "1\n##start\ns 0 0\n##end\ne 1 1\n", http.StatusUnprocessableEntity, "invalid_data_format", "ERROR: invalid data format, no path from ##start to ##end"},
+		{"application/json", "{", http.StatusBadRequest, "bad_request", "ERROR: invalid JSON"},
+		{"text/plain", strings.Repeat("#", 100), http.StatusRequestEntityTooLarge, "too_large", "ERROR: request body larger than 64 bytes"},
+	}
+
+	for _, test := range tests {
+		var body apiError
+		status := postJSON(t, server.URL+"/solve", test.contentType, test.body, &body)
+		if status != test.status || body.Code != test.code || !strings.HasPrefix(body.Error, test.message) {
+			t.Errorf("POST /solve %q returned %d %+v, expected %d %s %q", test.body, status, body, test.status, test.code, test.message)
+		}
+	}
+
+	// The farm problem is also given without the prefix
+	var body apiError
+	postJSON(t, server.URL+"/solve", "text/plain", "0\n", &body)
+	if body.Detail != "invalid number of ants" {
+		t.Errorf("Expected detail 'invalid number of ants', got %q", body.Detail)
+	}
+}
+
+// TestAPI_Verify tests checking transcripts
+func TestAPI_Verify(t *testing.T) {
+	server := httptest.NewServer(newServer("", defaultConfig()))
+	defer server.Close()
+
+	transcript, err := os.ReadFile("testdata/example.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result verifyResponse
+	status := postJSON(t, server.URL+"/verify", "text/plain", string(transcript), &result)
+	if status != http.StatusOK || !result.Valid || result.Turns != 4 {
+		t.Errorf("POST /verify (golden) returned %d %+v", status, result)
+	}
+
+	farm, _ := os.ReadFile("testdata/example.txt")
+	request, _ := json.Marshal(verifyRequest{Farm: string(farm), Moves: [][]string{{"L1-0"}}})
+	status = postJSON(t, server.URL+"/verify", "application/json", string(request), &result)
+	if status != http.StatusOK || result.Valid || len(result.Violations) == 0 {
+		t.Errorf("POST /verify (illegal) returned %d %+v", status, result)
+	}
+	if len(result.Violations) > 0 && result.Violations[0].Move != "L1-0" {
+		t.Errorf("Expected first violation on L1-0, got %+v", result.Violations[0])
+	}
+}
+
+// TestAPI_Health tests the health check
+func TestAPI_Health(t *testing.T) {
+	server := httptest.NewServer(newServer("", defaultConfig()))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /health returned %d", resp.StatusCode)
+	}
+}
+
+// TestWithTimeout tests that slow work is stopped after the timeout and
+// that no more than the allowed requests run at once
+func TestWithTimeout(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.timeout = 10 * time.Millisecond
+
+	_, err := withTimeout(context.Background(), cfg, nil, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, errTimeout) {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+
+	slots := make(chan struct{}, 1)
+	slots <- struct{}{} // Another request is being solved
+	_, err = withTimeout(context.Background(), cfg, slots, func(ctx context.Context) (int, error) {
+		t.Error("work ran without a free slot")
+		return 0, nil
+	})
+	if !errors.Is(err, errBusy) {
+		t.Errorf("Expected busy error, got %v", err)
+	}
+}
+
+// TestSolveFarmContext tests that the path search stops once the context is done
+func TestSolveFarmContext(t *testing.T) {
+	// Every room links to every other, so the paths can't all be listed in time
+	lines := []string{"10", "##start", "s 0 0", "##end", "e 1 0"}
+	for i := range 14 {
+		lines = append(lines, fmt.Sprintf("r%d %d 1", i, i))
+	}
+	for i := range 14 {
+		lines = append(lines, fmt.Sprintf("s-r%d", i), fmt.Sprintf("r%d-e", i))
+		for j := range i {
+			lines = append(lines, fmt.Sprintf("r%d-r%d", j, i))
+		}
+	}
+	farm, err := lemin.BuildFarm(lines)
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := solveFarmContext(ctx, farm, lemin.ClassicRules, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Search took %v to stop", elapsed)
+	}
+
+	if _, err := solveFarmContext(context.Background(), farm, lemin.ClassicRules, 100); !errors.Is(err, ErrTooManyPaths) {
+		t.Errorf("Expected too many paths error, got %v", err)
+	}
+}
+
+// TestAPI_VerifyTimeout tests that a slow /verify is stopped after the timeout
+func TestAPI_VerifyTimeout(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.timeout = time.Nanosecond
+	cfg.limits.solves = 0 // Let the expired request through to the verifier
+	server := httptest.NewServer(newServer("", cfg))
+	defer server.Close()
+
+	// Many ants and many turns, each moving an ant that already arrived
+	body := "100000\n##start\ns 0 0\n##end\ne 1 0\ns-e\n" + strings.Repeat("L1-e\n", 200000)
+
+	var resp apiError
+	status := postJSON(t, server.URL+"/verify", "text/plain", body, &resp)
+	if status != http.StatusServiceUnavailable || resp.Code != "timeout" {
+		t.Errorf("POST /verify returned %d %+v, expected 503 timeout", status, resp)
+	}
+}
+
+// TestAPI_Limits tests that farms over the limits are refused
+func TestAPI_Limits(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.limits.rooms = 3
+	cfg.limits.ants = 5
+	server := httptest.NewServer(newServer("", cfg))
+	defer server.Close()
+
+	tests := []struct {
+		body    string
+		message string
+	}{
+		{"1\n##start\ns 0 0\na 1 0\nb 2 0\n##end\ne 3 0\ns-a\na-b\nb-e\n", "ERROR: farm too large to solve: 4 rooms, at most 3"},
+		{"6\n##start\ns 0 0\n##end\ne 1 0\ns-e\n", "ERROR: farm too large to solve: 6 ants, at most 5"},
+	}
+	for _, test := range tests {
+		var body apiError
+		status := postJSON(t, server.URL+"/solve", "text/plain", test.body, &body)
+		if status != http.StatusRequestEntityTooLarge || body.Code != "too_large" || body.Error != test.message {
+			t.Errorf("POST /solve returned %d %+v, expected 413 too_large %q", status, body, test.message)
+		}
+	}
+}
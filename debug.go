@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// runDebug handles the debug subcommand:
+// go run . debug [--strict] [--rules name] [--tie-break list] [--avoid rooms] <filename>
+func runDebug(args []string) {
+	defaults := defaultConfig()
+
+	flags := flag.NewFlagSet("debug", flag.ExitOnError)
+	strict := flags.Bool("strict", false, "reject input that doesn't follow the canonical lem-in order")
+	rules := flags.String("rules", defaults.rules.Name, "movement rules: classic, strict or relaxed")
+	tieBreak := flags.String("tie-break", "", "comma-separated tie-breakers between plans with the same turns: moves, paths, load, avoid")
+	avoid := flags.String("avoid", "", "comma-separated rooms to steer ants away from, besides ##avoid rooms (implies --tie-break avoid)")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("ERROR: usage --> go run . debug [--strict] [--rules name] [--tie-break list] [--avoid rooms] <filename>")
+		return
+	}
+
+	cfg := defaults
+	cfg.parse.Strict = *strict
+	ruleSet, err := lemin.RulesByName(*rules)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	cfg.rules = ruleSet
+	cfg.ties, err = ParseTieBreakers(*tieBreak, *avoid)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	content, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Println("ERROR: could not read file:", err)
+		return
+	}
+
+	d, err := newDebugger(string(content), cfg, os.Stdout)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	d.run(os.Stdin)
+}
+
+// debugger steps through a solved farm, one turn at a time
+type debugger struct {
+	farm      *lemin.Farm
+	rules     lemin.Rules
+	paths     [][]*lemin.Room       // Every path from start to end
+	best      PathCombination       // The paths the ants take
+	turns     [][]string            // Moves of each turn
+	positions []map[int]*lemin.Room // Ant positions before the first turn and after each turn
+	stats     *Stats
+	turn      int // Turn being looked at, 0 before the first
+	out       io.Writer
+}
+
+// debugCommand is one command of the debugger
+type debugCommand struct {
+	usage string
+	help  string
+	run   func(d *debugger, args []string) error
+}
+
+// debugCommands lists every command by name. It is filled in init because
+// help refers back to it.
+var debugCommands map[string]debugCommand
+
+func init() {
+	debugCommands = map[string]debugCommand{
+		"help":  {"help", "list the commands", (*debugger).cmdHelp},
+		"paths": {"paths", "list every path from start to end", (*debugger).cmdPaths},
+		"combo": {"combo", "show the chosen paths and their estimated turns", (*debugger).cmdCombo},
+		"step":  {"step [n]", "go forward n turns (1 by default)", (*debugger).cmdStep},
+		"back":  {"back [n]", "go back n turns (1 by default)", (*debugger).cmdBack},
+		"goto":  {"goto <turn>", "jump to a turn, 0 is before the first move", (*debugger).cmdGoto},
+		"turn":  {"turn", "show the current turn", (*debugger).cmdTurn},
+		"ant":   {"ant <id>", "show where an ant is and its trip", (*debugger).cmdAnt},
+		"room":  {"room <name>", "show a room, its tunnels and the ant in it", (*debugger).cmdRoom},
+	}
+}
+
+// newDebugger parses and solves the farm, then runs the whole simulation so
+// any turn can be looked at
+func newDebugger(content string, cfg config, out io.Writer) (*debugger, error) {
+	farm, err := lemin.BuildFarmWithOptions(lemin.ParseInput(content), cfg.parse)
+	if err != nil {
+		return nil, err
+	}
+	best, err := solveFarm(farm, cfg.rules, cfg.ties...)
+	if err != nil {
+		return nil, err
+	}
+	turns := RunSimulationWithRules(farm, best.Paths, cfg.rules)
+
+	return &debugger{
+		farm:      farm,
+		rules:     cfg.rules,
+		paths:     FindAllPaths(farm),
+		best:      best,
+		turns:     turns,
+		positions: ReplayPositions(farm, turns),
+		stats:     ComputeStats(farm, best.Paths, turns),
+		out:       out,
+	}, nil
+}
+
+// run reads commands from in until it ends or "quit"
+func (d *debugger) run(in io.Reader) {
+	fmt.Fprintf(d.out, "Farm loaded: %d rooms, %d ants, %d turns. Type help for the commands.\n",
+		d.farm.NumRooms(), d.farm.AntCount(), len(d.turns))
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(d.out, "(lem-in) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(d.out)
+			return
+		}
+		if !d.execute(scanner.Text()) {
+			return
+		}
+	}
+}
+
+// execute runs one command line and reports whether to keep going
+func (d *debugger) execute(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return true
+	}
+	if fields[0] == "quit" || fields[0] == "exit" {
+		return false
+	}
+
+	cmd, ok := debugCommands[fields[0]]
+	if !ok {
+		fmt.Fprintf(d.out, "ERROR: unknown command %q, type help for the list\n", fields[0])
+		return true
+	}
+	if err := cmd.run(d, fields[1:]); err != nil {
+		fmt.Fprintln(d.out, "ERROR:", err)
+	}
+	return true
+}
+
+// cmdHelp lists the commands
+func (d *debugger) cmdHelp(args []string) error {
+	for _, name := range slices.Sorted(maps.Keys(debugCommands)) {
+		cmd := debugCommands[name]
+		fmt.Fprintf(d.out, "  %-12s %s\n", cmd.usage, cmd.help)
+	}
+	fmt.Fprintf(d.out, "  %-12s %s\n", "quit", "leave the debugger")
+	return nil
+}
+
+// cmdPaths lists every path from start to end, marking the chosen ones
+func (d *debugger) cmdPaths(args []string) error {
+	fmt.Fprintf(d.out, "%d paths from %s to %s:\n", len(d.paths), d.farm.Start().Name(), d.farm.End().Name())
+	for i, path := range d.paths {
+		mark := " "
+		if d.chosenPath(path) >= 0 {
+			mark = "*"
+		}
+		fmt.Fprintf(d.out, "%s %3d  length %-3d %s\n", mark, i+1, len(path)-1, roomNames(path))
+	}
+	return nil
+}
+
+// cmdCombo shows the chosen paths with their ants and the estimated turns
+func (d *debugger) cmdCombo(args []string) error {
+	fmt.Fprintf(d.out, "%d paths, estimated %d turns (EstimateTurns), simulated %d turns, %s rules\n",
+		len(d.best.Paths), d.best.Turns, len(d.turns), d.rules.Name)
+	for i, path := range d.stats.Paths {
+		fmt.Fprintf(d.out, "  %d: length %d, %d ants, %s\n", i+1, path.Length, path.Ants, strings.Join(path.Rooms, "-"))
+	}
+	return nil
+}
+
+// cmdStep goes forward some turns
+func (d *debugger) cmdStep(args []string) error {
+	n, err := optionalCount(args)
+	if err != nil {
+		return err
+	}
+	return d.goTo(d.turn + n)
+}
+
+// cmdBack goes back some turns
+func (d *debugger) cmdBack(args []string) error {
+	n, err := optionalCount(args)
+	if err != nil {
+		return err
+	}
+	return d.goTo(d.turn - n)
+}
+
+// cmdGoto jumps to a turn
+func (d *debugger) cmdGoto(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: goto <turn>")
+	}
+	turn, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid turn %q", args[0])
+	}
+	return d.goTo(turn)
+}
+
+// goTo moves to a turn and shows it
+func (d *debugger) goTo(turn int) error {
+	if turn < 0 || turn > len(d.turns) {
+		return fmt.Errorf("turn %d is out of range 0-%d", turn, len(d.turns))
+	}
+	d.turn = turn
+	return d.cmdTurn(nil)
+}
+
+// cmdTurn shows the moves of the current turn and where the ants are
+func (d *debugger) cmdTurn(args []string) error {
+	if d.turn == 0 {
+		fmt.Fprintf(d.out, "Turn 0/%d: all ants in %s\n", len(d.turns), d.farm.Start().Name())
+		return nil
+	}
+	fmt.Fprintf(d.out, "Turn %d/%d: %s\n", d.turn, len(d.turns), strings.Join(d.turns[d.turn-1], " "))
+
+	var ants []string
+	for id := 1; id <= d.farm.AntCount(); id++ {
+		room := d.positions[d.turn][id]
+		if room != d.farm.Start() && room != d.farm.End() {
+			ants = append(ants, fmt.Sprintf("A%d@%s", id, room.Name()))
+		}
+	}
+	if len(ants) > 0 {
+		fmt.Fprintln(d.out, "  In the farm:", strings.Join(ants, " "))
+	}
+	return nil
+}
+
+// cmdAnt shows where an ant is at the current turn and its whole trip
+func (d *debugger) cmdAnt(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: ant <id>")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id < 1 || id > d.farm.AntCount() {
+		return fmt.Errorf("no ant %s (ants are 1-%d)", args[0], d.farm.AntCount())
+	}
+
+	ant := d.stats.Ants[id-1]
+	fmt.Fprintf(d.out, "Ant %d is in %s at turn %d\n", id, d.positions[d.turn][id].Name(), d.turn)
+	if ant.Path >= 0 {
+		fmt.Fprintf(d.out, "  path %d: %s\n", ant.Path+1, strings.Join(d.stats.Paths[ant.Path].Rooms, "-"))
+	}
+	fmt.Fprintf(d.out, "  launched turn %d, arrived turn %d, waited %d, blocked %d\n",
+		ant.Launched, ant.Arrived, ant.Waiting, ant.Blocked)
+	return nil
+}
+
+// cmdRoom shows a room, its tunnels and who is in it at the current turn
+func (d *debugger) cmdRoom(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: room <name>")
+	}
+	room := d.farm.Room(args[0])
+	if room == nil {
+		return fmt.Errorf("unknown room %s", args[0])
+	}
+
+	kind := ""
+	switch room {
+	case d.farm.Start():
+		kind = " (start)"
+	case d.farm.End():
+		kind = " (end)"
+	}
+	fmt.Fprintf(d.out, "Room %s%s at %d %d\n", room.Name(), kind, room.X(), room.Y())
+
+	var links []string
+	for _, link := range room.Links() {
+		links = append(links, link.Name())
+	}
+	fmt.Fprintln(d.out, "  tunnels to:", strings.Join(links, " "))
+
+	var onPaths []string
+	for i, path := range d.best.Paths {
+		if slices.Contains(path, room) {
+			onPaths = append(onPaths, strconv.Itoa(i+1))
+		}
+	}
+	if len(onPaths) > 0 {
+		fmt.Fprintln(d.out, "  on chosen paths:", strings.Join(onPaths, " "))
+	}
+
+	var ants []string
+	for id := 1; id <= d.farm.AntCount(); id++ {
+		if d.positions[d.turn][id] == room {
+			ants = append(ants, strconv.Itoa(id))
+		}
+	}
+	if len(ants) > 0 {
+		fmt.Fprintf(d.out, "  ants at turn %d: %s\n", d.turn, strings.Join(ants, " "))
+	}
+	return nil
+}
+
+// chosenPath returns the index of path among the chosen paths, or -1
+func (d *debugger) chosenPath(path []*lemin.Room) int {
+	for i, chosen := range d.best.Paths {
+		if slices.Equal(chosen, path) {
+			return i
+		}
+	}
+	return -1
+}
+
+// optionalCount reads the number of turns of step and back, 1 when not given
+func optionalCount(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid number of turns %q", args[0])
+	}
+	return n, nil
+}
+
+// roomNames joins the names of the rooms of a path with '-'
+func roomNames(path []*lemin.Room) string {
+	names := make([]string, len(path))
+	for i, room := range path {
+		names[i] = room.Name()
+	}
+	return strings.Join(names, "-")
+}
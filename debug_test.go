@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestDebugger runs a scripted session on the example farm
+func TestDebugger(t *testing.T) {
+	content, err := os.ReadFile("testdata/example.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	d, err := newDebugger(string(content), defaultConfig(), &out)
+	if err != nil {
+		t.Fatalf("newDebugger returned error: %v", err)
+	}
+	d.run(strings.NewReader("paths\ncombo\nstep 2\nback\nant 3\nroom 2\ngoto 9\ngoto 4\nnope\nquit\nstep\n"))
+
+	session := out.String()
+	for _, want := range []string{
+		"23 paths from 1 to 0",
+		"*   1  length 3   1-2-4-0",
+		"2 paths, estimated 4 turns (EstimateTurns), simulated 4 turns",
+		"Turn 2/4: L1-4 L2-5 L3-2",
+		"Turn 1/4: L1-2 L2-3",
+		"Ant 3 is in 1 at turn 1",
+		"launched turn 2, arrived turn 4, waited 1",
+		"Room 2 at 16 7",
+		"ants at turn 1: 1",
+		"ERROR: turn 9 is out of range 0-4",
+		"Turn 4/4: L2-0 L3-0",
+		`ERROR: unknown command "nope"`,
+	} {
+		if !strings.Contains(session, want) {
+			t.Errorf("Expected %q in the session:\n%s", want, session)
+		}
+	}
+
+	// Nothing runs after quit
+	if d.turn != 4 {
+		t.Errorf("Expected to stay on turn 4 after quit, got %d", d.turn)
+	}
+}
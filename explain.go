@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// explainLimit is the most candidate combinations or rejected paths listed one by one
+const explainLimit = 30
+
+// Candidate is one path combination the solver considered
+type Candidate struct {
+	Paths    [][]*lemin.Room // In EstimateTurns order
+	Ants     []int           // Ants given to each path
+	Turns    int             // Estimated turns
+	Improved bool            // Whether it beat every combination before it
+}
+
+// Explanation records why the solver chose its paths
+type Explanation struct {
+	AntCount   int
+	Paths      [][]*lemin.Room // Every path from start to end
+	Candidates []Candidate     // Every combination, in the order considered
+	Best       PathCombination
+}
+
+// ExplainPathCombination runs the same search as FindOptimalPathCombination
+// and records every combination it considers
+func ExplainPathCombination(antCount int, paths [][]*lemin.Room, ties ...TieBreaker) *Explanation {
+	e := &Explanation{AntCount: antCount, Paths: paths}
+	e.Best, _ = searchPathCombinations(context.Background(), antCount, paths, lemin.ClassicRules, ties, func(combo [][]*lemin.Room, turns int, improved bool) {
+		e.Candidates = append(e.Candidates, Candidate{
+			Paths:    combo,
+			Ants:     DistributeAnts(antCount, combo),
+			Turns:    turns,
+			Improved: improved,
+		})
+	})
+	return e
+}
+
+// WriteReport writes the candidates, then how each chosen path lowered the
+// turns and why every other path was left out
+func (e *Explanation) WriteReport(w io.Writer) error {
+	fmt.Fprintf(w, "Explain: %d ants, %d paths found, %d combinations considered\n\n",
+		e.AntCount, len(e.Paths), len(e.Candidates))
+
+	// The combinations that became the best are always listed, the others up to the limit
+	fmt.Fprintln(w, "Combinations (lengths / ants per path -> turns):")
+	hidden := 0
+	for i, c := range e.Candidates {
+		if !c.Improved && i >= explainLimit {
+			hidden++
+			continue
+		}
+		var parts []string
+		for j, path := range c.Paths {
+			parts = append(parts, fmt.Sprintf("%d/%d", len(path)-1, c.Ants[j]))
+		}
+		verdict := ""
+		if c.Improved {
+			verdict = "  new best"
+		}
+		fmt.Fprintf(w, "  %-30s -> %d%s\n", strings.Join(parts, " "), c.Turns, verdict)
+	}
+	if hidden > 0 {
+		fmt.Fprintf(w, "  ... and %d more, none better\n", hidden)
+	}
+
+	fmt.Fprintf(w, "\nChosen: %d paths, %d turns\n", len(e.Best.Paths), e.Best.Turns)
+	for k := range e.Best.Paths {
+		path := e.Best.Paths[k]
+		with := EstimateTurns(e.AntCount, clonePaths(e.Best.Paths[:k+1]))
+		if k == 0 {
+			fmt.Fprintf(w, "  + %s: alone takes %d turns\n", roomNames(path), with)
+			continue
+		}
+		without := EstimateTurns(e.AntCount, clonePaths(e.Best.Paths[:k]))
+		fmt.Fprintf(w, "  + %s: added, %d -> %d turns\n", roomNames(path), without, with)
+	}
+
+	fmt.Fprintln(w, "\nLeft out:")
+	listed := 0
+	for _, path := range e.Paths {
+		if slices.ContainsFunc(e.Best.Paths, func(chosen []*lemin.Room) bool { return slices.Equal(chosen, path) }) {
+			continue
+		}
+		if listed == explainLimit {
+			fmt.Fprintln(w, "  ...")
+			break
+		}
+		listed++
+
+		if shared := sharedRooms(path, e.Best.Paths); len(shared) > 0 {
+			fmt.Fprintf(w, "  - %s: shares %s with the chosen paths\n", roomNames(path), strings.Join(shared, ", "))
+			continue
+		}
+		turns := EstimateTurns(e.AntCount, append(clonePaths(e.Best.Paths), path))
+		fmt.Fprintf(w, "  - %s: adding it gives %d turns, not fewer than %d\n", roomNames(path), turns, e.Best.Turns)
+	}
+	if listed == 0 {
+		fmt.Fprintln(w, "  nothing")
+	}
+
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// sharedRooms returns the middle rooms of path that the other paths already use
+func sharedRooms(path []*lemin.Room, others [][]*lemin.Room) []string {
+	var shared []string
+	for _, room := range path[1 : len(path)-1] {
+		for _, other := range others {
+			if slices.Contains(other[1:len(other)-1], room) {
+				shared = append(shared, room.Name())
+				break
+			}
+		}
+	}
+	return shared
+}
+
+// clonePaths copies the list of paths, since EstimateTurns reorders it
+func clonePaths(paths [][]*lemin.Room) [][]*lemin.Room {
+	return append([][]*lemin.Room(nil), paths...)
+}
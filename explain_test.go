@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// TestExplainPathCombination tests that the explanation matches the solver and gives its reasons
+func TestExplainPathCombination(t *testing.T) {
+	farm, err := lemin.BuildFarm(lemin.ParseInput("4\n##start\ns 0 0\na 1 0\nb 1 1\nc 1 2\nd 2 2\n##end\ne 2 0\ns-a\na-e\ns-b\nb-e\na-b\ns-c\nc-d\nd-e"))
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+	paths := FindAllPaths(farm)
+
+	e := ExplainPathCombination(farm.AntCount(), paths)
+	best := FindOptimalPathCombination(farm.AntCount(), paths)
+	if e.Best.Turns != best.Turns || len(e.Best.Paths) != len(best.Paths) {
+		t.Errorf("Expected the same choice as FindOptimalPathCombination, got %+v and %+v", e.Best, best)
+	}
+
+	improved := 0
+	for _, c := range e.Candidates {
+		if c.Improved {
+			improved++
+		}
+	}
+	if len(e.Candidates) == 0 || improved == 0 {
+		t.Errorf("Expected candidates with at least one new best, got %d and %d", len(e.Candidates), improved)
+	}
+
+	var out bytes.Buffer
+	if err := e.WriteReport(&out); err != nil {
+		t.Fatalf("WriteReport returned error: %v", err)
+	}
+	for _, want := range []string{
+		"Chosen: 2 paths, 3 turns",
+		"+ s-b-e: added, 5 -> 3 turns",
+		"- s-a-b-e: shares a, b with the chosen paths",
+		"- s-c-d-e: adding it gives 3 turns, not fewer than 3",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in the report:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// FuzzSolve checks that every valid farm yields a legal transcript
+func FuzzSolve(f *testing.F) {
+	// Seed with the golden-file farms
+	files, _ := filepath.Glob(filepath.Join("testdata", "*.txt"))
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(content))
+	}
+	f.Add("2\n##start\nroom-a 0 0\n##end\nroom-b 1 0\nroom-a-room-b")
+	f.Add("5\n##start\ns 0 0\n##end\ne 1 0\ns-e\ne-s\ns-e")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		farm, err := lemin.BuildFarm(lemin.ParseInput(input))
+		if err != nil {
+			return
+		}
+
+		// Path search is exponential, so keep the farms small
+		if farm.NumRooms() > 10 || len(farm.Tunnels()) > 20 || farm.AntCount() > 100 {
+			t.Skip("farm too large for fuzzing")
+		}
+
+		allPaths := FindAllPaths(farm)
+		if len(allPaths) == 0 {
+			return
+		}
+		best := FindOptimalPathCombination(farm.AntCount(), allPaths)
+		if len(best.Paths) == 0 {
+			t.Fatal("paths found but no combination chosen")
+		}
+
+		// Each rule set accepts the moves it produced
+		for _, rules := range []lemin.Rules{lemin.ClassicRules, lemin.StrictRules, lemin.RelaxedRules} {
+			turns := RunSimulationWithRules(farm, best.Paths, rules)
+			for _, v := range lemin.VerifyMovesWithRules(farm, turns, rules) {
+				t.Errorf("illegal move under %s rules: %s", rules.Name, v)
+			}
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// update rewrites the golden files instead of comparing against them:
+// go test -run TestGolden -update
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// goldenTurns is the known number of turns for each solvable farm in testdata
+var goldenTurns = map[string]int{
+	"example.txt":  4,
+	"line.txt":     5,
+	"complex.txt":  8,
+	"direct.txt":   3,
+	"comments.txt": 4,
+}
+
+// TestGolden replays every farm in testdata through the solve pipeline
+// and compares the printed output with its .golden file
+func TestGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no farms found in testdata")
+	}
+
+	for _, file := range files {
+		name := filepath.Base(file)
+		t.Run(name, func(t *testing.T) {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Produce exactly what main would print
+			var out bytes.Buffer
+			cfg := defaultConfig()
+			if err := run(&out, string(content), cfg); err != nil {
+				fmt.Fprintln(&out, err)
+			}
+
+			goldenFile := strings.TrimSuffix(file, ".txt") + ".golden"
+			if *update {
+				if err := os.WriteFile(goldenFile, out.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if out.String() != string(want) {
+				t.Errorf("output differs from %s\ngot:\n%s\nwant:\n%s", goldenFile, out.String(), want)
+			}
+
+			wantTurns, solvable := goldenTurns[name]
+			if !solvable {
+				if !strings.HasPrefix(out.String(), "ERROR:") {
+					t.Errorf("expected an ERROR for %s, got:\n%s", name, out.String())
+				}
+				return
+			}
+			verifyOutput(t, string(content), out.String(), wantTurns)
+		})
+	}
+}
+
+// verifyOutput checks that the printed moves are legal for the farm and take the expected number of turns
+func verifyOutput(t *testing.T, content, output string, wantTurns int) {
+	t.Helper()
+
+	farm, err := lemin.BuildFarm(lemin.ParseInput(content))
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	// The moves follow the echoed input and a blank line
+	if !strings.HasPrefix(output, content) {
+		t.Fatal("output doesn't start with the original input")
+	}
+	var turns [][]string
+	for _, line := range strings.Split(output[len(content):], "\n") {
+		if strings.HasPrefix(line, "L") {
+			turns = append(turns, strings.Fields(line))
+		}
+	}
+
+	for _, v := range lemin.VerifyMoves(farm, turns) {
+		t.Errorf("illegal move: %s", v)
+	}
+	if len(turns) != wantTurns {
+		t.Errorf("Expected %d turns, got %d", wantTurns, len(turns))
+	}
+}
+
+// TestRun_JSON tests that the JSON trace holds the same moves as the text output
+func TestRun_JSON(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "example.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var text, trace bytes.Buffer
+	cfg := defaultConfig()
+	if err := run(&text, string(content), cfg); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	cfg.json = true
+	if err := run(&trace, string(content), cfg); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	var solution lemin.Solution
+	if err := json.Unmarshal(trace.Bytes(), &solution); err != nil {
+		t.Fatalf("invalid JSON trace: %v", err)
+	}
+	if solution.Turns != goldenTurns["example.txt"] || len(solution.Paths) == 0 {
+		t.Errorf("Expected %d turns and the chosen paths, got %d turns and %v", goldenTurns["example.txt"], solution.Turns, solution.Paths)
+	}
+
+	var lines []string
+	for _, moves := range solution.Moves {
+		lines = append(lines, strings.Join(moves, " "))
+	}
+	if !strings.HasSuffix(text.String(), "\n\n"+strings.Join(lines, "\n")+"\n") {
+		t.Errorf("JSON moves %v don't match the text output:\n%s", solution.Moves, text.String())
+	}
+}
@@ -0,0 +1,452 @@
+package lemin
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Room represents a single room in the ant farm. Rooms are only made by
+// BuildFarm and can't be changed afterwards: their fields are read through
+// methods, and those returning slices return copies.
+type Room struct {
+	name        string
+	id          int
+	x, y        int64
+	links       []*Room
+	annotations []string
+}
+
+// Name returns the name of the room
+func (r *Room) Name() string { return r.name }
+
+// ID returns the position of the room in Farm.Rooms, numbering the rooms from 0
+func (r *Room) ID() int { return r.id }
+
+// X returns the first coordinate of the room
+func (r *Room) X() int64 { return r.x }
+
+// Y returns the second coordinate of the room
+func (r *Room) Y() int64 { return r.y }
+
+// Links returns the rooms connected to this one, in the order the tunnels were defined
+func (r *Room) Links() []*Room { return slices.Clone(r.links) }
+
+// Annotations returns the comments and unknown commands written just before the room
+func (r *Room) Annotations() []string { return slices.Clone(r.annotations) }
+
+// Tunnel represents a link between two rooms, as written in the input
+type Tunnel struct {
+	from, to    *Room
+	annotations []string
+}
+
+// From returns the room written first in the link
+func (t *Tunnel) From() *Room { return t.from }
+
+// To returns the room written second in the link
+func (t *Tunnel) To() *Room { return t.to }
+
+// Annotations returns the comments and unknown commands written just before the tunnel
+func (t *Tunnel) Annotations() []string { return slices.Clone(t.annotations) }
+
+// Farm represents the entire ant colony. A farm is only made by BuildFarm
+// and never changes afterwards: its fields are read through methods, and
+// those returning slices return copies. Goroutines may therefore share one.
+type Farm struct {
+	rooms    map[string]*Room // All rooms in the farm
+	order    []*Room          // Rooms in the order they were defined
+	tunnels  []*Tunnel        // Tunnels in the order they were defined
+	start    *Room            // Starting room
+	end      *Room            // Destination room
+	antCount int              // Number of ants to move
+	graph    *Graph           // The tunnels in compact form, built once parsing is done
+
+	// Comments and unknown commands after the last room or tunnel
+	annotations []string
+
+	// Rule violations accepted because parsing wasn't strict
+	warnings []string
+}
+
+// Room returns the room called name, or nil when there is none
+func (f *Farm) Room(name string) *Room { return f.rooms[name] }
+
+// Rooms returns every room in the order they were defined, which is the order of their IDs
+func (f *Farm) Rooms() []*Room { return slices.Clone(f.order) }
+
+// NumRooms returns how many rooms the farm has
+func (f *Farm) NumRooms() int { return len(f.order) }
+
+// Tunnels returns every tunnel in the order they were defined
+func (f *Farm) Tunnels() []*Tunnel { return slices.Clone(f.tunnels) }
+
+// Start returns the room the ants start in
+func (f *Farm) Start() *Room { return f.start }
+
+// End returns the room the ants must reach
+func (f *Farm) End() *Room { return f.end }
+
+// AntCount returns the number of ants to move
+func (f *Farm) AntCount() int { return f.antCount }
+
+// Graph returns the tunnels in compact form, or nil for a farm not made by BuildFarm
+func (f *Farm) Graph() *Graph { return f.graph }
+
+// Annotations returns the comments and unknown commands after the last room or tunnel
+func (f *Farm) Annotations() []string { return slices.Clone(f.annotations) }
+
+// Warnings returns the rule violations accepted because parsing wasn't strict
+func (f *Farm) Warnings() []string { return slices.Clone(f.warnings) }
+
+// Command returns the value of the last "##key value" command written before the room
+func (r *Room) Command(key string) (string, bool) {
+	return lookupCommand(r.annotations, key)
+}
+
+// Command returns the value of the last "##key value" command written before the tunnel
+func (t *Tunnel) Command(key string) (string, bool) {
+	return lookupCommand(t.annotations, key)
+}
+
+// lookupCommand searches annotations for "##key" or "##key value"
+func lookupCommand(annotations []string, key string) (string, bool) {
+	value, found := "", false
+	for _, line := range annotations {
+		if !strings.HasPrefix(line, "##") {
+			continue // Plain comment
+		}
+		name, rest, _ := strings.Cut(line[2:], " ")
+		if name == key {
+			value, found = strings.TrimSpace(rest), true
+		}
+	}
+	return value, found
+}
+
+// ParseOptions controls how strictly BuildFarmWithOptions checks the input.
+//
+// Strict mode follows the canonical lem-in order: the number of ants, then
+// the rooms, then the links, with ##start and ##end given exactly once and
+// each directly followed by its room. Lenient mode accepts these relaxations
+// and records a warning for each:
+//   - rooms may be defined after links
+//   - ##start or ##end may be repeated; the last room marked wins
+//   - comments or links may come between ##start or ##end and its room
+//   - rooms may share coordinates or lie outside MinCoord..MaxCoord
+//
+// The zero value checks only the order: any coordinates are allowed.
+type ParseOptions struct {
+	Strict          bool  // Report rule violations as errors instead of warnings
+	UniquePositions bool  // No two rooms may share the same coordinates
+	HasRange        bool  // Coordinates must lie within MinCoord..MaxCoord
+	MinCoord        int64 // Smallest allowed coordinate, if HasRange
+	MaxCoord        int64 // Largest allowed coordinate, if HasRange
+}
+
+// DefaultParseOptions returns the options used by BuildFarm:
+// lenient parsing that warns about shared positions and negative coordinates
+func DefaultParseOptions() ParseOptions {
+	return ParseOptions{
+		UniquePositions: true,
+		HasRange:        true,
+		MinCoord:        0,
+		MaxCoord:        math.MaxInt64,
+	}
+}
+
+// farmParser holds the state needed while reading the lines of a farm
+type farmParser struct {
+	farm *Farm
+	opts ParseOptions
+
+	// Flags to track special commands
+	expectStart, expectEnd bool
+	seenStart, seenEnd     bool
+	command                string // ##start or ##end still waiting for its room
+
+	// Set once the first link is read
+	seenLinks bool
+
+	// Comments and unknown commands waiting for the next room or tunnel
+	annotations []string
+
+	// Rooms by position, to find rooms sharing coordinates
+	positions map[[2]int64]*Room
+}
+
+// BuildFarm reads the input and creates the farm structure using the default options
+func BuildFarm(lines []string) (*Farm, error) {
+	return BuildFarmWithOptions(lines, DefaultParseOptions())
+}
+
+// BuildFarmWithOptions reads the input and creates the farm structure.
+// Rule violations allowed in lenient mode are returned by Farm.Warnings.
+func BuildFarmWithOptions(lines []string, opts ParseOptions) (*Farm, error) {
+	if len(lines) == 0 {
+		return nil, errors.New("ERROR: invalid data format, empty input")
+	}
+
+	// First line should be the number of ants
+	antCount, err := strconv.Atoi(lines[0])
+	if err != nil || antCount <= 0 {
+		return nil, errors.New("ERROR: invalid data format, invalid number of ants")
+	}
+
+	// Create a new farm
+	p := &farmParser{
+		farm: &Farm{
+			antCount: antCount,
+			rooms:    make(map[string]*Room),
+		},
+		opts:      opts,
+		positions: make(map[[2]int64]*Room),
+	}
+	farm := p.farm
+
+	// Process each line after the ant count
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		// Skip empty lines
+		if line == "" {
+			continue
+		}
+
+		// Rooms have spaces between their parts, comments never count as rooms
+		isRoom := !strings.HasPrefix(line, "#") && len(strings.Fields(line)) > 1
+
+		// ##start and ##end must be directly followed by their room
+		if p.command != "" && !isRoom {
+			if err := p.violation("%s not followed by a room", p.command); err != nil {
+				return nil, err
+			}
+			p.command = ""
+		}
+
+		// Handle special commands and comments
+		if strings.HasPrefix(line, "#") {
+			if line == "##start" || line == "##end" {
+				if err := p.processCommand(line); err != nil {
+					return nil, err
+				}
+			} else {
+				p.annotations = append(p.annotations, line)
+			}
+			continue
+		}
+
+		if isRoom {
+			// Rooms come before all links
+			if p.seenLinks {
+				if err := p.violation("room defined after links: %s", line); err != nil {
+					return nil, err
+				}
+			}
+			err := p.processRoom(line)
+			if err != nil {
+				return nil, err
+			}
+		} else if strings.Contains(line, "-") {
+			// This line defines a tunnel between rooms
+			p.seenLinks = true
+			err := p.processLink(line)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, fmt.Errorf("ERROR: invalid data format, unknown line format: %s", line)
+		}
+	}
+
+	// Keep whatever follows the last room or tunnel
+	farm.annotations = p.annotations
+
+	// Make sure we have both start and end rooms
+	if farm.start == nil {
+		return nil, errors.New("ERROR: invalid data format, missing ##start room")
+	}
+	if farm.end == nil {
+		return nil, errors.New("ERROR: invalid data format, missing ##end room")
+	}
+	if farm.start == farm.end {
+		return nil, errors.New("ERROR: invalid data format, ##start and ##end are the same room")
+	}
+
+	farm.graph = newGraph(farm)
+	return farm, nil
+}
+
+// violation reports a broken rule: an error in strict mode, a warning otherwise
+func (p *farmParser) violation(format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+	if p.opts.Strict {
+		return errors.New("ERROR: invalid data format, " + msg)
+	}
+	p.farm.warnings = append(p.farm.warnings, msg)
+	return nil
+}
+
+// processCommand handles ##start and ##end, each of which should appear once
+func (p *farmParser) processCommand(line string) error {
+	if line == "##start" {
+		if p.seenStart {
+			if err := p.violation("multiple ##start commands"); err != nil {
+				return err
+			}
+		}
+		p.seenStart, p.expectStart = true, true
+	} else {
+		if p.seenEnd {
+			if err := p.violation("multiple ##end commands"); err != nil {
+				return err
+			}
+		}
+		p.seenEnd, p.expectEnd = true, true
+	}
+
+	p.command = line
+	return nil
+}
+
+// processRoom handles a room definition line, attaching the pending annotations to it
+func (p *farmParser) processRoom(line string) error {
+	farm := p.farm
+
+	// Split the line into parts: name x y
+	tokens := strings.Fields(line)
+	if len(tokens) != 3 {
+		return fmt.Errorf("ERROR: invalid data format, malformed room: %s", line)
+	}
+
+	name, xStr, yStr := tokens[0], tokens[1], tokens[2]
+
+	// Room names cannot start with 'L' or '#'
+	if strings.HasPrefix(name, "L") || strings.HasPrefix(name, "#") {
+		return fmt.Errorf("ERROR: invalid data format, invalid room name: %s", name)
+	}
+
+	// Check for duplicate room names
+	if _, exists := farm.rooms[name]; exists {
+		return fmt.Errorf("ERROR: invalid data format, duplicate room name: %s", name)
+	}
+
+	// Parse the coordinates
+	x, err1 := strconv.ParseInt(xStr, 10, 64)
+	y, err2 := strconv.ParseInt(yStr, 10, 64)
+	if err1 != nil || err2 != nil {
+		return fmt.Errorf("ERROR: invalid data format, invalid coordinates in room: %s", line)
+	}
+
+	// Check the coordinates against the allowed range and the other rooms
+	if p.opts.HasRange && (x < p.opts.MinCoord || x > p.opts.MaxCoord || y < p.opts.MinCoord || y > p.opts.MaxCoord) {
+		if err := p.violation("coordinates out of range in room: %s", line); err != nil {
+			return err
+		}
+	}
+	position := [2]int64{x, y}
+	other, taken := p.positions[position]
+	if taken && p.opts.UniquePositions {
+		if err := p.violation("rooms %s and %s share coordinates %d %d", other.name, name, x, y); err != nil {
+			return err
+		}
+	}
+
+	// Create the room
+	room := &Room{
+		name:        name,
+		id:          len(farm.order),
+		x:           x,
+		y:           y,
+		annotations: p.annotations,
+	}
+	p.annotations = nil
+
+	// Add room to the farm
+	farm.rooms[name] = room
+	farm.order = append(farm.order, room)
+	if !taken {
+		p.positions[position] = room
+	}
+
+	// Set as start or end room if flagged
+	p.command = ""
+	if p.expectStart {
+		farm.start = room
+		p.expectStart = false
+	}
+	if p.expectEnd {
+		farm.end = room
+		p.expectEnd = false
+	}
+
+	return nil
+}
+
+// processLink handles a tunnel definition line, attaching the pending annotations to it.
+// A duplicate tunnel leaves them for the next room or tunnel.
+func (p *farmParser) processLink(line string) error {
+	farm := p.farm
+
+	// Split the line: room1-room2
+	room1, room2, err := splitLink(line, farm)
+	if err != nil {
+		return err
+	}
+
+	// Prevent rooms from linking to themselves
+	if room1 == room2 {
+		return fmt.Errorf("ERROR: invalid data format, self-linked room: %s", line)
+	}
+
+	// Add bidirectional link if it doesn't already exist
+	if !IsLinked(room1, room2) {
+		room1.links = append(room1.links, room2)
+		room2.links = append(room2.links, room1)
+		farm.tunnels = append(farm.tunnels, &Tunnel{from: room1, to: room2, annotations: p.annotations})
+		p.annotations = nil
+	}
+
+	return nil
+}
+
+// splitLink finds the rooms joined by a link line. Room names may contain '-',
+// so every '-' is tried as the separator and kept only when both sides name
+// existing rooms. More than one such split makes the link ambiguous.
+func splitLink(line string, farm *Farm) (*Room, *Room, error) {
+	var room1, room2 *Room
+	matches := 0
+
+	for i := 0; i < len(line); i++ {
+		if line[i] != '-' {
+			continue
+		}
+		from, ok1 := farm.rooms[line[:i]]
+		to, ok2 := farm.rooms[line[i+1:]]
+		if ok1 && ok2 {
+			room1, room2 = from, to
+			matches++
+		}
+	}
+
+	switch {
+	case matches == 0:
+		return nil, nil, fmt.Errorf("ERROR: invalid data format, link references unknown room(s): %s", line)
+	case matches > 1:
+		return nil, nil, fmt.Errorf("ERROR: invalid data format, ambiguous link: %s", line)
+	}
+	return room1, room2, nil
+}
+
+// IsLinked checks if two rooms are already connected
+func IsLinked(a, b *Room) bool {
+	for _, link := range a.links {
+		if link == b {
+			return true
+		}
+	}
+	return false
+}
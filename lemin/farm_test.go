@@ -0,0 +1,420 @@
+package lemin
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestBuildFarm_EmptyInput tests what happens with empty input
+func TestBuildFarm_EmptyInput(t *testing.T) {
+	_, err := BuildFarm([]string{})
+	if err == nil {
+		t.Error("BuildFarm(empty) should return error but didn't")
+	}
+}
+
+// TestBuildFarm_InvalidAnts tests invalid ant counts
+func TestBuildFarm_InvalidAnts(t *testing.T) {
+	tests := [][]string{
+		{"0"},   // Zero ants
+		{"-1"},  // Negative ants
+		{"abc"}, // Non-number
+		{""},    // Empty
+	}
+
+	for _, test := range tests {
+		_, err := BuildFarm(test)
+		if err == nil {
+			t.Errorf("BuildFarm(%v) should return error but didn't", test)
+		}
+	}
+}
+
+// TestBuildFarm_SelfLink tests rooms linking to themselves
+func TestBuildFarm_SelfLink(t *testing.T) {
+	lines := []string{
+		"1",
+		"##start",
+		"A 0 0",
+		"##end",
+		"B 1 1",
+		"A-A", // Room linking to itself
+	}
+	_, err := BuildFarm(lines)
+	if err == nil {
+		t.Error("BuildFarm(self-link) should return error but didn't")
+	}
+}
+
+// TestBuildFarm_DuplicateRoom tests duplicate room names
+func TestBuildFarm_DuplicateRoom(t *testing.T) {
+	lines := []string{
+		"1",
+		"##start",
+		"A 0 0",
+		"##end",
+		"B 1 1",
+		"A 0 0", // Duplicate room name
+	}
+	_, err := BuildFarm(lines)
+	if err == nil {
+		t.Error("BuildFarm(duplicate room) should return error but didn't")
+	}
+}
+
+// TestBuildFarm_MissingStartEnd tests missing start or end markers
+func TestBuildFarm_MissingStartEnd(t *testing.T) {
+	lines := []string{
+		"1",
+		"A 0 0",
+		"B 1 1",
+		"A-B",
+	}
+	_, err := BuildFarm(lines)
+	if err == nil {
+		t.Error("BuildFarm(missing start/end) should return error but didn't")
+	}
+}
+
+// TestBuildFarm_SameStartEnd tests a room marked as both start and end
+func TestBuildFarm_SameStartEnd(t *testing.T) {
+	lines := []string{
+		"1",
+		"##start",
+		"##end",
+		"A 0 0",
+	}
+	_, err := BuildFarm(lines)
+	if err == nil {
+		t.Error("BuildFarm(same start and end) should return error but didn't")
+	}
+}
+
+// TestBuildFarm_InvalidRoomName tests invalid room names
+func TestBuildFarm_InvalidRoomName(t *testing.T) {
+	// Test room name starting with L
+	lines1 := []string{
+		"1",
+		"##start",
+		"L123 0 0", // Invalid: starts with L
+		"##end",
+		"B 1 1",
+	}
+	_, err1 := BuildFarm(lines1)
+	if err1 == nil {
+		t.Error("BuildFarm(room starting with L) should return error but didn't")
+	}
+
+	// Test room name starting with # (but not a comment line)
+	lines2 := []string{
+		"1",
+		"##start",
+		"A 0 0",
+		"##end",
+		"#bad 0 0", // This will be treated as comment, not room
+		"B 1 1",
+	}
+	_, err2 := BuildFarm(lines2)
+	if err2 != nil {
+		t.Errorf("BuildFarm with comment line should work, got error: %v", err2)
+	}
+
+	// Test room with spaces in name (should be caught by malformed room check)
+	lines3 := []string{
+		"1",
+		"##start",
+		"A 0 0",
+		"##end",
+		"bad name 0 0", // This has spaces, should be malformed
+		"B 1 1",
+	}
+	_, err3 := BuildFarm(lines3)
+	if err3 == nil {
+		t.Error("BuildFarm(room with spaces) should return error but didn't")
+	}
+}
+
+// TestBuildFarm_ValidCase tests a valid farm
+func TestBuildFarm_ValidCase(t *testing.T) {
+	lines := []string{
+		"2",
+		"##start",
+		"start 0 0",
+		"middle 1 1",
+		"##end",
+		"end 2 2",
+		"start-middle",
+		"middle-end",
+	}
+
+	farm, err := BuildFarm(lines)
+	if err != nil {
+		t.Errorf("BuildFarm(valid) returned error: %v", err)
+		return
+	}
+
+	if farm.AntCount() != 2 {
+		t.Errorf("Expected 2 ants, got %d", farm.AntCount())
+	}
+
+	if farm.Start().Name() != "start" {
+		t.Errorf("Expected start room 'start', got '%s'", farm.Start().Name())
+	}
+
+	if farm.End().Name() != "end" {
+		t.Errorf("Expected end room 'end', got '%s'", farm.End().Name())
+	}
+
+	if farm.NumRooms() != 3 {
+		t.Errorf("Expected 3 rooms, got %d", farm.NumRooms())
+	}
+}
+
+// TestBuildFarm_Annotations tests that comments and unknown commands are kept
+func TestBuildFarm_Annotations(t *testing.T) {
+	lines := []string{
+		"1",
+		"# entrance",
+		"##start",
+		"start 0 0",
+		"##color red",
+		"##label  the middle ",
+		"##color blue",
+		"middle 1 1",
+		"##end",
+		"end 2 2",
+		"##capacity 2",
+		"start-middle",
+		"middle-end",
+		"# done",
+	}
+
+	farm, err := BuildFarm(lines)
+	if err != nil {
+		t.Fatalf("BuildFarm(annotated) returned error: %v", err)
+	}
+
+	if got := farm.Start().Annotations(); len(got) != 1 || got[0] != "# entrance" {
+		t.Errorf("Expected start annotations [# entrance], got %v", got)
+	}
+	if len(farm.End().Annotations()) != 0 {
+		t.Errorf("Expected no end annotations, got %v", farm.End().Annotations())
+	}
+
+	middle := farm.Room("middle")
+	if color, ok := middle.Command("color"); !ok || color != "blue" {
+		t.Errorf("Expected color blue, got %q (found %v)", color, ok)
+	}
+	if label, _ := middle.Command("label"); label != "the middle" {
+		t.Errorf("Expected label 'the middle', got %q", label)
+	}
+	if _, ok := middle.Command("capacity"); ok {
+		t.Error("Expected no capacity command on room middle")
+	}
+
+	if capacity, ok := farm.Tunnels()[0].Command("capacity"); !ok || capacity != "2" {
+		t.Errorf("Expected tunnel capacity 2, got %q (found %v)", capacity, ok)
+	}
+	if got := farm.Annotations(); len(got) != 1 || got[0] != "# done" {
+		t.Errorf("Expected trailing annotations [# done], got %v", got)
+	}
+}
+
+// TestBuildFarm_HyphenatedNames tests links between rooms whose names contain '-'
+func TestBuildFarm_HyphenatedNames(t *testing.T) {
+	lines := []string{
+		"1",
+		"##start",
+		"room-a 0 0",
+		"b 1 1",
+		"##end",
+		"room-c 2 2",
+		"room-a-b ",
+		"  b-room-c",
+	}
+
+	farm, err := BuildFarm(lines)
+	if err != nil {
+		t.Fatalf("BuildFarm(hyphenated names) returned error: %v", err)
+	}
+
+	if !IsLinked(farm.Room("room-a"), farm.Room("b")) {
+		t.Error("Expected room-a to be linked to b")
+	}
+	if !IsLinked(farm.Room("b"), farm.Room("room-c")) {
+		t.Error("Expected b to be linked to room-c")
+	}
+}
+
+// TestBuildFarm_AmbiguousLink tests a link that matches two pairs of rooms
+func TestBuildFarm_AmbiguousLink(t *testing.T) {
+	lines := []string{
+		"1",
+		"##start",
+		"a 0 0",
+		"a-b 1 1",
+		"b-c 2 2",
+		"##end",
+		"c 3 3",
+		"a-b-c", // a + b-c or a-b + c
+	}
+
+	_, err := BuildFarm(lines)
+	if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("BuildFarm(ambiguous link) should return ambiguity error, got %v", err)
+	}
+}
+
+// TestBuildFarm_Coordinates tests coordinate validation in strict and lenient mode
+func TestBuildFarm_Coordinates(t *testing.T) {
+	tests := []struct {
+		room   string // The middle room of a start-middle-end farm
+		reason string // Expected violation, empty if valid
+	}{
+		{"m 1 1", ""},
+		{"m 9223372036854775807 1", ""},
+		{"m 0 0", "share coordinates"},
+		{"m -1 5", "out of range"},
+		{"m 5 -1", "out of range"},
+	}
+
+	for _, test := range tests {
+		lines := []string{"1", "##start", "s 0 0", test.room, "##end", "e 2 2", "s-m", "m-e"}
+
+		// Strict mode rejects the farm
+		opts := DefaultParseOptions()
+		opts.Strict = true
+		_, err := BuildFarmWithOptions(lines, opts)
+		if test.reason == "" && err != nil {
+			t.Errorf("strict BuildFarm(%s) returned error: %v", test.room, err)
+		}
+		if test.reason != "" && (err == nil || !strings.Contains(err.Error(), test.reason)) {
+			t.Errorf("strict BuildFarm(%s) should fail with %q, got %v", test.room, test.reason, err)
+		}
+
+		// Lenient mode accepts it with a warning
+		farm, err := BuildFarm(lines)
+		if err != nil {
+			t.Errorf("lenient BuildFarm(%s) returned error: %v", test.room, err)
+			continue
+		}
+		if test.reason == "" && len(farm.Warnings()) != 0 {
+			t.Errorf("lenient BuildFarm(%s) warned: %v", test.room, farm.Warnings())
+		}
+		if test.reason != "" && (len(farm.Warnings()) != 1 || !strings.Contains(farm.Warnings()[0], test.reason)) {
+			t.Errorf("lenient BuildFarm(%s) should warn %q, got %v", test.room, test.reason, farm.Warnings())
+		}
+	}
+
+	// Custom ranges and shared positions can be allowed
+	opts := ParseOptions{Strict: true, HasRange: true, MinCoord: -10, MaxCoord: 10}
+	lines := []string{"1", "##start", "s -10 0", "m -10 0", "##end", "e 10 10", "s-m", "m-e"}
+	if _, err := BuildFarmWithOptions(lines, opts); err != nil {
+		t.Errorf("BuildFarm with custom range returned error: %v", err)
+	}
+	lines[5] = "e 11 10"
+	if _, err := BuildFarmWithOptions(lines, opts); err == nil {
+		t.Error("BuildFarm with coordinate above the range should return error but didn't")
+	}
+
+	// The zero value puts no limit on coordinates
+	lines = []string{"1", "##start", "s -5 0", "m 9223372036854775807 3", "##end", "e 5 5", "s-m", "m-e"}
+	if _, err := BuildFarmWithOptions(lines, ParseOptions{Strict: true}); err != nil {
+		t.Errorf("strict BuildFarm without a range returned error: %v", err)
+	}
+}
+
+// TestBuildFarm_StrictOrder tests the canonical ordering rules in strict and lenient mode
+func TestBuildFarm_StrictOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  []string
+		reason string
+	}{
+		{
+			"room after links",
+			[]string{"1", "##start", "s 0 0", "##end", "e 1 1", "s-e", "x 2 2"},
+			"room defined after links",
+		},
+		{
+			"multiple ##start",
+			[]string{"1", "##start", "a 0 0", "##start", "s 1 0", "##end", "e 1 1", "s-e"},
+			"multiple ##start",
+		},
+		{
+			"multiple ##end",
+			[]string{"1", "##start", "s 0 0", "##end", "a 1 0", "##end", "e 1 1", "s-e"},
+			"multiple ##end",
+		},
+		{
+			"##start followed by a comment",
+			[]string{"1", "##start", "# the entrance", "s 0 0", "##end", "e 1 1", "s-e"},
+			"##start not followed by a room",
+		},
+		{
+			"##end followed by a link",
+			[]string{"1", "##start", "s 0 0", "x 1 0", "s-x", "##end", "x-s", "e 1 1", "s-e"},
+			"##end not followed by a room",
+		},
+	}
+
+	for _, test := range tests {
+		// Strict mode rejects the input
+		opts := DefaultParseOptions()
+		opts.Strict = true
+		_, err := BuildFarmWithOptions(test.lines, opts)
+		if err == nil || !strings.Contains(err.Error(), test.reason) {
+			t.Errorf("strict BuildFarm(%s) should fail with %q, got %v", test.name, test.reason, err)
+		}
+
+		// Lenient mode accepts it and records the relaxation
+		farm, err := BuildFarm(test.lines)
+		if err != nil {
+			t.Errorf("lenient BuildFarm(%s) returned error: %v", test.name, err)
+			continue
+		}
+		found := false
+		for _, warning := range farm.Warnings() {
+			if strings.Contains(warning, test.reason) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("lenient BuildFarm(%s) should warn %q, got %v", test.name, test.reason, farm.Warnings())
+		}
+	}
+
+	// The last room marked as start wins in lenient mode
+	farm, err := BuildFarm(tests[1].lines)
+	if err == nil && farm.Start().Name() != "s" {
+		t.Errorf("Expected start room 's', got '%s'", farm.Start().Name())
+	}
+
+	// A canonical farm passes strict mode
+	opts := DefaultParseOptions()
+	opts.Strict = true
+	lines := []string{"1", "# comment", "##start", "s 0 0", "##end", "e 1 1", "# tunnels", "s-e"}
+	if _, err := BuildFarmWithOptions(lines, opts); err != nil {
+		t.Errorf("strict BuildFarm(canonical) returned error: %v", err)
+	}
+}
+
+// TestFarm_ReadOnly tests that changing the slices a farm returns leaves the farm as it was
+func TestFarm_ReadOnly(t *testing.T) {
+	farm, err := BuildFarm([]string{"1", "# first", "##start", "s 0 0", "a 1 1", "##end", "e 2 0", "s-a", "a-e", "# last"})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	rooms, tunnels, links := farm.Rooms(), farm.Tunnels(), farm.Room("a").Links()
+	rooms[0], tunnels[0], links[0] = nil, nil, nil
+	farm.Annotations()[0] = "changed"
+	farm.Start().Annotations()[0] = "changed"
+
+	if farm.Rooms()[0] != farm.Start() || farm.Tunnels()[0] == nil || farm.Room("a").Links()[0] != farm.Start() {
+		t.Error("Changing the returned rooms, tunnels or links changed the farm")
+	}
+	if farm.Annotations()[0] != "# last" || farm.Start().Annotations()[0] != "# first" {
+		t.Error("Changing the returned annotations changed the farm")
+	}
+}
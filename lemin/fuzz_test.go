@@ -0,0 +1,130 @@
+package lemin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fuzzSeeds are the starting inputs shared by the fuzz targets
+var fuzzSeeds = []string{
+	"3\n##start\nA 0 0\nB 1 0\nC 2 0\n##end\nD 3 0\nA-B\nB-C\nC-D",
+	"4\n##start\nstart 0 0\nmiddle 1 1\n##end\nend 2 0\nstart-end\nstart-middle\nmiddle-end",
+	"2\n# comment\n##start\ns 0 0\n##color red\na 1 0\n##end\ne 2 0\ns-a\na-e\n",
+	"1\n##start\n##start\ns 0 0\n##end\ne  1   1\ns-e",
+	"2\n##start\nroom-a 0 0\n##end\nroom-b 1 0\nroom-a-room-b",
+	"1\n##start\na 0 0\n##end\nb-c 1 0\na-b-c\na-b 2 0\nc 3 0\nc-a-b",
+	"1\n##start\n##end\nA 0 0",
+	"5\n##start\ns 0 0\n##end\ne 1 0\ns-e\ne-s\ns-e",
+	"1\nA-B\n##start\nA 0 0\n##end\nB 0 0",
+	"-1\n##start\nA 0 0",
+	"",
+}
+
+// FuzzBuildFarm checks that parsing never panics and that accepted farms are consistent
+func FuzzBuildFarm(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		farm, err := BuildFarm(ParseInput(input))
+		if err != nil {
+			if !strings.HasPrefix(err.Error(), "ERROR: ") {
+				t.Errorf("error without ERROR prefix: %v", err)
+			}
+			return
+		}
+
+		if farm.Start() == nil || farm.End() == nil {
+			t.Fatal("farm accepted without start or end room")
+		}
+		if farm.AntCount() <= 0 {
+			t.Errorf("farm accepted with %d ants", farm.AntCount())
+		}
+		for id, room := range farm.Rooms() {
+			if farm.Room(room.Name()) != room || room.ID() != id {
+				t.Errorf("room %q not found by its name or ID %d", room.Name(), id)
+			}
+			for _, link := range room.Links() {
+				if !IsLinked(link, room) {
+					t.Errorf("link %s-%s is not bidirectional", room.Name(), link.Name())
+				}
+			}
+		}
+	})
+}
+
+// FuzzRoundTrip checks that writing a parsed farm and parsing it again gives an equal farm
+func FuzzRoundTrip(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		farm, err := BuildFarm(ParseInput(input))
+		if err != nil {
+			return
+		}
+
+		var sb strings.Builder
+		if _, err := farm.WriteTo(&sb); err != nil {
+			t.Fatal(err)
+		}
+		text := sb.String()
+		again, err := BuildFarm(ParseInput(text))
+		if err != nil {
+			t.Fatalf("re-parsing failed: %v\n%s", err, text)
+		}
+		if diff := compareFarms(farm, again); diff != "" {
+			t.Errorf("farm changed after round trip: %s\n%s", diff, text)
+		}
+	})
+}
+
+// compareFarms describes the first difference between two farms, or returns ""
+func compareFarms(a, b *Farm) string {
+	if a.AntCount() != b.AntCount() {
+		return fmt.Sprintf("ant count %d != %d", a.AntCount(), b.AntCount())
+	}
+	if a.Start().Name() != b.Start().Name() || a.End().Name() != b.End().Name() {
+		return "start or end room differs"
+	}
+	if len(a.rooms) != len(b.rooms) {
+		return fmt.Sprintf("%d rooms != %d rooms", len(a.rooms), len(b.rooms))
+	}
+	for name, room := range a.rooms {
+		other, ok := b.rooms[name]
+		if !ok {
+			return "missing room " + name
+		}
+		if room.X() != other.X() || room.Y() != other.Y() {
+			return "coordinates differ for room " + name
+		}
+		if strings.Join(room.Annotations(), "\n") != strings.Join(other.Annotations(), "\n") {
+			return "annotations differ for room " + name
+		}
+	}
+	if strings.Join(linkNames(a), " ") != strings.Join(linkNames(b), " ") {
+		return "links differ"
+	}
+	if strings.Join(a.Annotations(), "\n") != strings.Join(b.Annotations(), "\n") {
+		return "trailing annotations differ"
+	}
+	return ""
+}
+
+// linkNames lists every tunnel once as "a-b" with a < b, sorted
+func linkNames(farm *Farm) []string {
+	var links []string
+	for _, room := range farm.Rooms() {
+		for _, link := range room.Links() {
+			if room.Name() < link.Name() {
+				links = append(links, room.Name()+"-"+link.Name())
+			}
+		}
+	}
+	sort.Strings(links)
+	return links
+}
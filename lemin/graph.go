@@ -0,0 +1,98 @@
+package lemin
+
+import "iter"
+
+// Graph is a compact view of the tunnels of a farm for the solver and the
+// simulator. Rooms are numbered by their ID and the tunnels leaving each room
+// are stored one after the other (compressed sparse rows), in the order they
+// were defined. Like the farm, a graph never changes once built.
+type Graph struct {
+	rooms      []*Room // Rooms by ID, the same as Farm.Rooms
+	offsets    []int32 // Where the neighbours of each room start in adj, plus the total at the end
+	adj        []int32 // Neighbour IDs of every room, one after the other
+	start, end int32   // IDs of the start and end rooms
+}
+
+// newGraph builds the compact graph of a farm
+func newGraph(farm *Farm) *Graph {
+	g := &Graph{
+		rooms:   farm.order,
+		offsets: make([]int32, len(farm.order)+1),
+		adj:     make([]int32, 0, 2*len(farm.tunnels)),
+		start:   int32(farm.start.id),
+		end:     int32(farm.end.id),
+	}
+	for i, room := range farm.order {
+		for _, link := range room.links {
+			g.adj = append(g.adj, int32(link.id))
+		}
+		g.offsets[i+1] = int32(len(g.adj))
+	}
+	return g
+}
+
+// Room returns the room numbered id
+func (g *Graph) Room(id int32) *Room { return g.rooms[id] }
+
+// NumRooms returns how many rooms the graph has
+func (g *Graph) NumRooms() int { return len(g.rooms) }
+
+// NumEdges returns how many tunnels the graph has, counting each direction
+// once: every Edge result is below it
+func (g *Graph) NumEdges() int { return len(g.adj) }
+
+// Start returns the ID of the start room
+func (g *Graph) Start() int32 { return g.start }
+
+// End returns the ID of the end room
+func (g *Graph) End() int32 { return g.end }
+
+// Neighbors returns the IDs of the rooms linked to room id
+func (g *Graph) Neighbors(id int32) iter.Seq[int32] {
+	return func(yield func(int32) bool) {
+		for _, neighbor := range g.adj[g.offsets[id]:g.offsets[id+1]] {
+			if !yield(neighbor) {
+				return
+			}
+		}
+	}
+}
+
+// Edge returns a number standing for the tunnel from-to in this direction,
+// below NumEdges, or -1 when the rooms aren't linked
+func (g *Graph) Edge(from, to int32) int {
+	for i := g.offsets[from]; i < g.offsets[from+1]; i++ {
+		if g.adj[i] == to {
+			return int(i)
+		}
+	}
+	return -1
+}
+
+// Bitset is a set of small non-negative integers, such as room IDs
+type Bitset []uint64
+
+// NewBitset returns an empty set that can hold 0 to n-1
+func NewBitset(n int) Bitset {
+	return make(Bitset, (n+63)/64)
+}
+
+// Has reports whether i is in the set
+func (b Bitset) Has(i int) bool {
+	return b[i/64]&(1<<(i%64)) != 0
+}
+
+// Set adds i to the set
+func (b Bitset) Set(i int) {
+	b[i/64] |= 1 << (i % 64)
+}
+
+// Unset removes i from the set
+func (b Bitset) Unset(i int) {
+	b[i/64] &^= 1 << (i % 64)
+}
+
+// Clear removes everything from the set
+func (b Bitset) Clear() {
+	clear(b)
+}
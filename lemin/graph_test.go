@@ -0,0 +1,77 @@
+package lemin
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestGraph tests that the compact graph keeps the rooms and tunnels of the farm
+func TestGraph(t *testing.T) {
+	farm, err := BuildFarm([]string{"1", "##start", "s 0 0", "a 1 0", "b 1 1", "##end", "e 2 0", "s-a", "s-b", "a-e", "b-a"})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+	g := farm.Graph()
+
+	for id, room := range farm.Rooms() {
+		if room.ID() != id || g.Room(int32(id)) != room {
+			t.Errorf("Room %s has ID %d at index %d", room.Name(), room.ID(), id)
+		}
+	}
+	if g.NumRooms() != farm.NumRooms() {
+		t.Errorf("Expected %d rooms, got %d", farm.NumRooms(), g.NumRooms())
+	}
+	if g.Room(g.Start()) != farm.Start() || g.Room(g.End()) != farm.End() {
+		t.Errorf("Expected start s and end e, got %s and %s", g.Room(g.Start()).Name(), g.Room(g.End()).Name())
+	}
+
+	want := map[string][]string{"s": {"a", "b"}, "a": {"s", "e", "b"}, "b": {"s", "a"}, "e": {"a"}}
+	for _, room := range farm.Rooms() {
+		var got []string
+		for id := range g.Neighbors(int32(room.ID())) {
+			got = append(got, g.Room(id).Name())
+		}
+		if !slices.Equal(got, want[room.Name()]) {
+			t.Errorf("Expected neighbours %v of %s, got %v", want[room.Name()], room.Name(), got)
+		}
+	}
+
+	// Every tunnel gets its own edge in each direction
+	edges := make(map[int]bool)
+	for _, room := range farm.Rooms() {
+		for _, link := range room.Links() {
+			i := g.Edge(int32(room.ID()), int32(link.ID()))
+			if i < 0 || i >= g.NumEdges() || edges[i] {
+				t.Errorf("Edge(%s, %s) returned %d", room.Name(), link.Name(), i)
+			}
+			edges[i] = true
+		}
+	}
+	if len(edges) != g.NumEdges() {
+		t.Errorf("Expected %d edges, got %d", g.NumEdges(), len(edges))
+	}
+	if i := g.Edge(g.Start(), int32(farm.End().ID())); i != -1 {
+		t.Errorf("Expected no edge s-e, got %d", i)
+	}
+}
+
+// TestBitset tests adding and removing integers across words
+func TestBitset(t *testing.T) {
+	b := NewBitset(130)
+	for _, i := range []int{0, 63, 64, 129} {
+		b.Set(i)
+	}
+	b.Unset(63)
+
+	for i := range 130 {
+		want := i == 0 || i == 64 || i == 129
+		if b.Has(i) != want {
+			t.Errorf("Has(%d) = %v, want %v", i, b.Has(i), want)
+		}
+	}
+
+	b.Clear()
+	if b.Has(0) || b.Has(129) {
+		t.Error("Expected an empty set after Clear")
+	}
+}
@@ -0,0 +1,154 @@
+package lemin
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// RoomHeat is how busy one room was during a run
+type RoomHeat struct {
+	Room       *Room
+	Visits     int  // Ants that moved into the room
+	Occupied   int  // Turns that ended with an ant in the room
+	Bottleneck bool // Among the rooms between start and end with the most visits
+}
+
+// TunnelHeat is how often one tunnel was used during a run, in either direction
+type TunnelHeat struct {
+	Tunnel     *Tunnel
+	Traversals int
+}
+
+// Heatmap counts room occupancy and tunnel use over a whole run
+type Heatmap struct {
+	Turns   int
+	Rooms   []RoomHeat   // In definition order
+	Tunnels []TunnelHeat // In definition order
+}
+
+// ComputeHeatmap replays the moves of each turn on the farm. Moves that don't
+// follow a tunnel are still counted for the room they enter. Every ant passes
+// through a room in a turn of its own, so the rooms between start and end that
+// most ants visit set a lower bound on the number of turns: they are marked as
+// bottlenecks.
+func ComputeHeatmap(farm *Farm, turns [][]string) *Heatmap {
+	heat := &Heatmap{
+		Turns:   len(turns),
+		Rooms:   make([]RoomHeat, len(farm.order)),
+		Tunnels: make([]TunnelHeat, len(farm.tunnels)),
+	}
+
+	roomIndex := make(map[*Room]int, len(farm.order))
+	for i, room := range farm.order {
+		heat.Rooms[i] = RoomHeat{Room: room}
+		roomIndex[room] = i
+	}
+	tunnelIndex := make(map[[2]*Room]int, 2*len(farm.tunnels))
+	for i, tunnel := range farm.tunnels {
+		heat.Tunnels[i] = TunnelHeat{Tunnel: tunnel}
+		tunnelIndex[[2]*Room{tunnel.from, tunnel.to}] = i
+		tunnelIndex[[2]*Room{tunnel.to, tunnel.from}] = i
+	}
+
+	position := make(map[int]*Room)
+	for id := 1; id <= farm.antCount; id++ {
+		position[id] = farm.start
+	}
+
+	for _, moves := range turns {
+		for _, move := range moves {
+			antID, roomName, err := ParseAntMove(move)
+			current, known := position[antID]
+			next, exists := farm.rooms[roomName]
+			if err != nil || !known || !exists {
+				continue
+			}
+
+			heat.Rooms[roomIndex[next]].Visits++
+			if i, ok := tunnelIndex[[2]*Room{current, next}]; ok {
+				heat.Tunnels[i].Traversals++
+			}
+			position[antID] = next
+		}
+
+		occupied := make(map[*Room]bool)
+		for _, room := range position {
+			occupied[room] = true
+		}
+		for room := range occupied {
+			heat.Rooms[roomIndex[room]].Occupied++
+		}
+	}
+
+	busiest := 0
+	for _, room := range heat.Rooms {
+		if room.Room != farm.start && room.Room != farm.end {
+			busiest = max(busiest, room.Visits)
+		}
+	}
+	for i, room := range heat.Rooms {
+		heat.Rooms[i].Bottleneck = busiest > 0 && room.Visits == busiest &&
+			room.Room != farm.start && room.Room != farm.end
+	}
+
+	return heat
+}
+
+// WriteTable writes the room and tunnel counts as aligned text tables
+func (h *Heatmap) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Heatmap over %d turns\n\n", h.Turns)
+
+	fmt.Fprintln(tw, "Room\tX\tY\tVisits\tOccupied\t")
+	for _, room := range h.Rooms {
+		mark := ""
+		if room.Bottleneck {
+			mark = "bottleneck"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\n", room.Room.name, room.Room.x, room.Room.y, room.Visits, room.Occupied, mark)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "Tunnel\tTraversals\t")
+	for _, tunnel := range h.Tunnels {
+		fmt.Fprintf(tw, "%s-%s\t%d\t\n", tunnel.Tunnel.from.name, tunnel.Tunnel.to.name, tunnel.Traversals)
+	}
+
+	return tw.Flush()
+}
+
+// WriteCSV writes one record per room, then one per tunnel:
+// kind,name,x,y,count,occupied,bottleneck. count is the visits of a room or
+// the traversals of a tunnel; tunnels leave the position fields empty.
+func (h *Heatmap) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"kind", "name", "x", "y", "count", "occupied", "bottleneck"})
+
+	for _, room := range h.Rooms {
+		cw.Write([]string{
+			"room",
+			room.Room.name,
+			strconv.FormatInt(room.Room.x, 10),
+			strconv.FormatInt(room.Room.y, 10),
+			strconv.Itoa(room.Visits),
+			strconv.Itoa(room.Occupied),
+			strconv.FormatBool(room.Bottleneck),
+		})
+	}
+	for _, tunnel := range h.Tunnels {
+		cw.Write([]string{
+			"tunnel",
+			tunnel.Tunnel.from.name + "-" + tunnel.Tunnel.to.name,
+			"", "",
+			strconv.Itoa(tunnel.Traversals),
+			"", "",
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
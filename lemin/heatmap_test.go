@@ -0,0 +1,50 @@
+package lemin
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestComputeHeatmap tests room and tunnel counts and the bottleneck
+func TestComputeHeatmap(t *testing.T) {
+	farm, err := BuildFarm([]string{"3", "##start", "s 0 0", "a 1 0", "b 1 1", "##end", "e 2 0", "s-a", "a-e", "s-b", "b-e"})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+	turns := [][]string{{"L1-a", "L2-b"}, {"L1-e", "L2-e", "L3-a"}, {"L3-e"}}
+
+	heat := ComputeHeatmap(farm, turns)
+
+	want := map[string][2]int{"s": {0, 1}, "a": {2, 2}, "b": {1, 1}, "e": {3, 2}} // Visits, occupied turns
+	for _, room := range heat.Rooms {
+		if got := [2]int{room.Visits, room.Occupied}; got != want[room.Room.Name()] {
+			t.Errorf("Room %s: expected visits and occupied %v, got %v", room.Room.Name(), want[room.Room.Name()], got)
+		}
+		if room.Bottleneck != (room.Room.Name() == "a") {
+			t.Errorf("Room %s: unexpected bottleneck %v", room.Room.Name(), room.Bottleneck)
+		}
+	}
+
+	traversals := []int{2, 2, 1, 1}
+	for i, tunnel := range heat.Tunnels {
+		if tunnel.Traversals != traversals[i] {
+			t.Errorf("Tunnel %s-%s: expected %d traversals, got %d", tunnel.Tunnel.From().Name(), tunnel.Tunnel.To().Name(), traversals[i], tunnel.Traversals)
+		}
+	}
+
+	var table, csv bytes.Buffer
+	if err := heat.WriteTable(&table); err != nil {
+		t.Fatalf("WriteTable returned error: %v", err)
+	}
+	if !strings.Contains(table.String(), "bottleneck") {
+		t.Errorf("Expected the bottleneck in the table, got:\n%s", table.String())
+	}
+	if err := heat.WriteCSV(&csv); err != nil {
+		t.Fatalf("WriteCSV returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != 1+4+4 || lines[2] != "room,a,1,0,2,2,true" || lines[5] != "tunnel,s-a,,,2,," {
+		t.Errorf("Unexpected CSV:\n%s", csv.String())
+	}
+}
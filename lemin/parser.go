@@ -1,12 +1,12 @@
-package main
+package lemin
 
 import (
 	"strings"
 )
 
-// parseInput splits the raw input text into individual lines, trimming any leading or trailing whitespace.
+// ParseInput splits the raw input text into individual lines, trimming any leading or trailing whitespace.
 // Returns a slice of strings, each representing one line of the original input.
-func parseInput(input string) []string {
+func ParseInput(input string) []string {
 	// Remove any extra spaces at the beginning and end
 	trimmed := strings.TrimSpace(input)
 
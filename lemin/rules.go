@@ -0,0 +1,72 @@
+package lemin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rules are the movement rules a run follows. Graders disagree on some
+// details, so the solver, the simulator and the verifier all take them from here.
+type Rules struct {
+	Name string
+
+	// SharedTunnels makes a tunnel carry one ant per turn in total. Otherwise
+	// it carries one ant per turn in each direction.
+	SharedTunnels bool
+
+	// DirectUnlimited lets a tunnel from start straight to end carry any
+	// number of ants in the same turn
+	DirectUnlimited bool
+}
+
+// Named rule sets
+var (
+	// ClassicRules: one ant per tunnel per direction per turn, the default
+	ClassicRules = Rules{Name: "classic"}
+
+	// StrictRules: one ant per tunnel per turn, whatever the direction
+	StrictRules = Rules{Name: "strict", SharedTunnels: true}
+
+	// RelaxedRules: like classic, but a start-end tunnel carries every ant at once
+	RelaxedRules = Rules{Name: "relaxed", DirectUnlimited: true}
+)
+
+// ruleSets lists the named rule sets in the order they are shown to users
+var ruleSets = []Rules{ClassicRules, StrictRules, RelaxedRules}
+
+// RulesByName returns the rule set called name
+func RulesByName(name string) (Rules, error) {
+	var names []string
+	for _, rules := range ruleSets {
+		if rules.Name == name {
+			return rules, nil
+		}
+		names = append(names, rules.Name)
+	}
+	return Rules{}, fmt.Errorf("ERROR: unknown rule set %q (expected one of %s)", name, strings.Join(names, ", "))
+}
+
+// TunnelKey identifies the use of the tunnel from-to within a turn: two moves
+// with the same key can't happen in the same turn. It returns "" when the
+// move has no limit under these rules.
+func (r Rules) TunnelKey(farm *Farm, from, to *Room) string {
+	if r.DirectUnlimited && from == farm.start && to == farm.end {
+		return ""
+	}
+	if r.SharedTunnels && to.name < from.name {
+		from, to = to, from
+	}
+	return from.name + "->" + to.name
+}
+
+// TunnelSlot is TunnelKey for the simulator: a number below g.NumEdges standing
+// for the use of the tunnel from-to, or -1 when the move has no limit
+func (r Rules) TunnelSlot(g *Graph, from, to int32) int {
+	if r.DirectUnlimited && from == g.start && to == g.end {
+		return -1
+	}
+	if r.SharedTunnels && to < from {
+		from, to = to, from
+	}
+	return g.Edge(from, to)
+}
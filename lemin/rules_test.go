@@ -0,0 +1,73 @@
+package lemin
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRulesByName tests the named rule sets and the error for unknown names
+func TestRulesByName(t *testing.T) {
+	for _, name := range []string{"classic", "strict", "relaxed"} {
+		rules, err := RulesByName(name)
+		if err != nil || rules.Name != name {
+			t.Errorf("RulesByName(%q) returned %+v, %v", name, rules, err)
+		}
+	}
+	if _, err := RulesByName("lenient"); err == nil || !strings.Contains(err.Error(), "classic, strict, relaxed") {
+		t.Errorf("Expected an error listing the rule sets, got %v", err)
+	}
+}
+
+// TestVerifyMovesWithRules tests the moves each rule set accepts
+func TestVerifyMovesWithRules(t *testing.T) {
+	farm, err := BuildFarm([]string{"3", "##start", "s 0 0", "a 1 0", "b 2 0", "##end", "e 3 0", "s-a", "a-b", "b-e", "s-e"})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		turns [][]string
+		valid map[string]bool // Whether each rule set accepts the turns
+	}{
+		{"direct one by one", [][]string{{"L1-e"}, {"L2-e"}, {"L3-e"}},
+			map[string]bool{"classic": true, "strict": true, "relaxed": true}},
+		{"direct all at once", [][]string{{"L1-e", "L2-e", "L3-e"}},
+			map[string]bool{"classic": false, "strict": false, "relaxed": true}},
+		{"opposite directions", [][]string{{"L1-a", "L2-e"}, {"L1-b", "L3-a"}, {"L1-a", "L3-b"}, {"L1-s"}},
+			map[string]bool{"classic": true, "strict": false, "relaxed": true}},
+	}
+
+	for _, tt := range tests {
+		for name, want := range tt.valid {
+			rules, _ := RulesByName(name)
+			violations := VerifyMovesWithRules(farm, tt.turns, rules)
+			tunnelReused := false
+			for _, v := range violations {
+				tunnelReused = tunnelReused || v.Reason == "tunnel already used this turn"
+			}
+			if tunnelReused == want {
+				t.Errorf("%s under %s rules: expected valid tunnel use %v, got %v", tt.name, name, want, violations)
+			}
+		}
+	}
+}
+
+// TestTunnelKey tests that strict rules share a tunnel between both directions
+func TestTunnelKey(t *testing.T) {
+	farm, err := BuildFarm([]string{"1", "##start", "s 0 0", "a 1 0", "b 2 0", "##end", "e 3 0", "s-a", "a-b", "b-e"})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+	a, b := farm.Room("a"), farm.Room("b")
+
+	if ClassicRules.TunnelKey(farm, a, b) == ClassicRules.TunnelKey(farm, b, a) {
+		t.Error("Expected classic rules to tell the directions apart")
+	}
+	if StrictRules.TunnelKey(farm, a, b) != StrictRules.TunnelKey(farm, b, a) {
+		t.Error("Expected strict rules to share the tunnel between directions")
+	}
+	if RelaxedRules.TunnelKey(farm, farm.Start(), farm.End()) != "" {
+		t.Error("Expected relaxed rules to leave the start-end tunnel unlimited")
+	}
+}
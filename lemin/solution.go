@@ -0,0 +1,77 @@
+package lemin
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Solution is the JSON form of a solved farm: its layout, the chosen paths,
+// the number of turns and the moves of every turn
+type Solution struct {
+	Ants  int            `json:"ants"`
+	Start string         `json:"start"`
+	End   string         `json:"end"`
+	Rooms []SolutionRoom `json:"rooms"`
+	Links [][2]string    `json:"links"`
+	Paths [][]string     `json:"paths,omitempty"`
+	Turns int            `json:"turns"`
+	Moves [][]string     `json:"moves,omitempty"`
+}
+
+// SolutionRoom is a room with its position
+type SolutionRoom struct {
+	Name string `json:"name"`
+	X    int64  `json:"x"`
+	Y    int64  `json:"y"`
+}
+
+// NewSolution collects the farm layout, the paths and the moves into a Solution
+func NewSolution(farm *Farm, paths [][]*Room, turns [][]string) *Solution {
+	solution := &Solution{
+		Ants:  farm.antCount,
+		Start: farm.start.name,
+		End:   farm.end.name,
+		Rooms: make([]SolutionRoom, 0, len(farm.order)),
+		Links: make([][2]string, 0, len(farm.tunnels)),
+		Paths: make([][]string, 0, len(paths)),
+		Turns: len(turns),
+		Moves: turns,
+	}
+
+	for _, room := range farm.order {
+		solution.Rooms = append(solution.Rooms, SolutionRoom{Name: room.name, X: room.x, Y: room.y})
+	}
+	for _, tunnel := range farm.tunnels {
+		solution.Links = append(solution.Links, [2]string{tunnel.from.name, tunnel.to.name})
+	}
+	for _, path := range paths {
+		names := make([]string, len(path))
+		for i, room := range path {
+			names[i] = room.name
+		}
+		solution.Paths = append(solution.Paths, names)
+	}
+
+	return solution
+}
+
+// FarmLines writes the farm part of the solution back as lem-in lines,
+// so a farm sent as JSON goes through the same parser as a text one
+func (s *Solution) FarmLines() []string {
+	lines := []string{strconv.Itoa(s.Ants)}
+
+	for _, room := range s.Rooms {
+		if room.Name == s.Start {
+			lines = append(lines, "##start")
+		}
+		if room.Name == s.End {
+			lines = append(lines, "##end")
+		}
+		lines = append(lines, fmt.Sprintf("%s %d %d", room.Name, room.X, room.Y))
+	}
+	for _, link := range s.Links {
+		lines = append(lines, link[0]+"-"+link[1])
+	}
+
+	return lines
+}
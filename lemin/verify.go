@@ -0,0 +1,236 @@
+package lemin
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Violation describes one broken rule found while replaying moves
+type Violation struct {
+	Turn   int    `json:"turn"`           // Turn number, starting at 1
+	Move   string `json:"move,omitempty"` // The offending move, empty for checks made after the turn
+	Reason string `json:"reason"`         // Human readable explanation
+}
+
+// String formats the violation for error messages
+func (v Violation) String() string {
+	if v.Move == "" {
+		return fmt.Sprintf("turn %d: %s", v.Turn, v.Reason)
+	}
+	return fmt.Sprintf("turn %d: %s: %s", v.Turn, v.Move, v.Reason)
+}
+
+// ParseAntMove reads a move like "L1-room2" and returns the ant number and room name
+func ParseAntMove(move string) (int, string, error) {
+	// Room names may contain '-', ant numbers can't, so split at the first one
+	idStr, roomName, ok := strings.Cut(strings.TrimPrefix(move, "L"), "-")
+	if !strings.HasPrefix(move, "L") || !ok || roomName == "" {
+		return 0, "", fmt.Errorf("malformed move %q", move)
+	}
+
+	antID, err := strconv.Atoi(idStr)
+	if err != nil || antID <= 0 {
+		return 0, "", fmt.Errorf("invalid ant number in move %q", move)
+	}
+
+	return antID, roomName, nil
+}
+
+// SplitTranscript separates lem-in output into the farm lines and the moves of each turn.
+// Move lines are the ones starting with 'L', which no room name or link can.
+func SplitTranscript(lines []string) ([]string, [][]string) {
+	var farmLines []string
+	var turns [][]string
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "L") {
+			turns = append(turns, strings.Fields(line))
+		} else {
+			farmLines = append(farmLines, line)
+		}
+	}
+
+	return farmLines, turns
+}
+
+// VerifyMoves replays the moves of each turn on the farm and reports every broken rule.
+// A legal run moves each ant at most once per turn along an existing tunnel,
+// uses each tunnel at most once per turn in each direction, never leaves two
+// ants in the same room (except start and end) and brings every ant to the end.
+func VerifyMoves(farm *Farm, turns [][]string) []Violation {
+	return VerifyMovesWithRules(farm, turns, ClassicRules)
+}
+
+// VerifyMovesWithRules checks the moves like VerifyMoves, limiting tunnel use
+// as the given rules say
+func VerifyMovesWithRules(farm *Farm, turns [][]string, rules Rules) []Violation {
+	violations, _ := VerifyMovesWithRulesContext(context.Background(), farm, turns, rules)
+	return violations
+}
+
+// VerifyMovesWithRulesContext is VerifyMovesWithRules that stops with the
+// context's error once ctx is done, checking it before every turn
+func VerifyMovesWithRulesContext(ctx context.Context, farm *Farm, turns [][]string, rules Rules) ([]Violation, error) {
+	var violations []Violation
+
+	checker := NewMoveChecker(farm, rules)
+	for _, moves := range turns {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		violations = append(violations, checker.CheckTurn(moves)...)
+	}
+
+	return append(violations, checker.Finish()...), nil
+}
+
+// MoveChecker replays moves one turn at a time and checks each against the
+// rules, for callers that need the verdict as the moves come in. Illegal
+// moves are not applied, except moves into a room that ends up crowded.
+type MoveChecker struct {
+	farm     *Farm
+	rules    Rules
+	position map[int]*Room // Room of every ant, start for ants that haven't moved
+	turn     int           // Turns started so far
+	ants     []int         // Ants in each room, by ID
+	crowded  []*Room       // Rooms other than start and end holding more than one ant
+
+	moved       map[int]bool    // Ants that already moved this turn
+	usedTunnels map[string]bool // Tunnels used this turn
+}
+
+// NewMoveChecker puts every ant in the start room
+func NewMoveChecker(farm *Farm, rules Rules) *MoveChecker {
+	c := &MoveChecker{
+		farm:     farm,
+		rules:    rules,
+		position: make(map[int]*Room),
+		ants:     make([]int, len(farm.order)),
+	}
+	for id := 1; id <= farm.antCount; id++ {
+		c.position[id] = farm.start
+	}
+	return c
+}
+
+// Turn returns the number of the current turn, 0 before the first
+func (c *MoveChecker) Turn() int {
+	return c.turn
+}
+
+// Position returns the room ant id is in, or nil when there is no such ant
+func (c *MoveChecker) Position(id int) *Room {
+	return c.position[id]
+}
+
+// CheckTurn checks all the moves of the next turn and returns every broken rule
+func (c *MoveChecker) CheckTurn(moves []string) []Violation {
+	var violations []Violation
+
+	c.StartTurn()
+	for _, move := range moves {
+		if reason := c.CheckMove(move); reason != "" {
+			violations = append(violations, Violation{c.turn, move, reason})
+		}
+	}
+
+	// After the turn, each room (except start and end) holds at most one ant
+	for _, room := range c.Crowded() {
+		violations = append(violations, Violation{c.turn, "", fmt.Sprintf("room %s holds more than one ant", room.name)})
+	}
+
+	return violations
+}
+
+// StartTurn begins the next turn
+func (c *MoveChecker) StartTurn() {
+	c.turn++
+	c.moved = make(map[int]bool)
+	c.usedTunnels = make(map[string]bool)
+}
+
+// CheckMove checks one move of the current turn and applies it if it is
+// legal. It returns why the move is illegal, or "".
+func (c *MoveChecker) CheckMove(move string) string {
+	antID, roomName, err := ParseAntMove(move)
+	if err != nil {
+		return err.Error()
+	}
+
+	current, ok := c.position[antID]
+	if !ok {
+		return "unknown ant"
+	}
+	next, ok := c.farm.rooms[roomName]
+	if !ok {
+		return "unknown room"
+	}
+
+	if c.moved[antID] {
+		return "ant moved twice in one turn"
+	}
+	if current == c.farm.end {
+		return "ant already reached the end"
+	}
+	if !IsLinked(current, next) {
+		return fmt.Sprintf("no tunnel from %s", current.name)
+	}
+
+	tunnelID := c.rules.TunnelKey(c.farm, current, next)
+	if tunnelID != "" && c.usedTunnels[tunnelID] {
+		return "tunnel already used this turn"
+	}
+
+	// Apply the move
+	c.usedTunnels[tunnelID] = true
+	c.moved[antID] = true
+	c.position[antID] = next
+	c.leave(current)
+	c.enter(next)
+	return ""
+}
+
+// enter counts an ant arriving in room, which is crowded from the second ant on
+func (c *MoveChecker) enter(room *Room) {
+	if room == c.farm.start || room == c.farm.end {
+		return
+	}
+	c.ants[room.id]++
+	if c.ants[room.id] == 2 {
+		c.crowded = append(c.crowded, room)
+	}
+}
+
+// leave counts an ant leaving room, which is no longer crowded with one ant left
+func (c *MoveChecker) leave(room *Room) {
+	if room == c.farm.start || room == c.farm.end {
+		return
+	}
+	c.ants[room.id]--
+	if c.ants[room.id] == 1 {
+		i := slices.Index(c.crowded, room)
+		c.crowded = slices.Delete(c.crowded, i, i+1)
+	}
+}
+
+// Crowded returns the rooms, other than start and end, that hold more than
+// one ant, in the order the rooms were defined
+func (c *MoveChecker) Crowded() []*Room {
+	crowded := slices.Clone(c.crowded)
+	slices.SortFunc(crowded, func(a, b *Room) int { return a.id - b.id })
+	return crowded
+}
+
+// Finish reports every ant that is not in the end room
+func (c *MoveChecker) Finish() []Violation {
+	var violations []Violation
+	for id := 1; id <= c.farm.antCount; id++ {
+		if c.position[id] != c.farm.end {
+			violations = append(violations, Violation{c.turn, "", fmt.Sprintf("ant %d did not reach the end", id)})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,102 @@
+package lemin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestVerifyMoves_Illegal tests that broken rules are reported
+func TestVerifyMoves_Illegal(t *testing.T) {
+	farm, err := BuildFarm([]string{
+		"2",
+		"##start",
+		"s 0 0",
+		"a 1 0",
+		"b 1 1",
+		"##end",
+		"e 2 0",
+		"s-a",
+		"a-e",
+		"s-b",
+		"b-e",
+		"a-b",
+	})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	tests := []struct {
+		turns  []string
+		reason string
+	}{
+		{[]string{"L1-a L2-b", "L1-e L2-e"}, ""},
+		{[]string{"L1-e"}, "no tunnel"},
+		{[]string{"L1-x"}, "unknown room"},
+		{[]string{"L3-a"}, "unknown ant"},
+		{[]string{"L1-a L2-b", "L2-a"}, "more than one ant"},
+		{[]string{"L1-a L1-e"}, "moved twice"},
+		{[]string{"X1-a"}, "malformed move"},
+		{[]string{"L1-a L2-b", "L1-e"}, "ant 2 did not reach the end"},
+	}
+
+	for _, test := range tests {
+		var turns [][]string
+		for _, line := range test.turns {
+			turns = append(turns, strings.Fields(line))
+		}
+
+		violations := VerifyMoves(farm, turns)
+		if test.reason == "" {
+			if len(violations) != 0 {
+				t.Errorf("VerifyMoves(%v) reported %v, expected none", test.turns, violations)
+			}
+			continue
+		}
+
+		found := false
+		for _, v := range violations {
+			if strings.Contains(v.String(), test.reason) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("VerifyMoves(%v) = %v, expected %q", test.turns, violations, test.reason)
+		}
+	}
+}
+
+// TestVerifyMoves_CrowdedUntilLeft tests that a crowded room is reported on
+// every turn until an ant leaves it
+func TestVerifyMoves_CrowdedUntilLeft(t *testing.T) {
+	farm, err := BuildFarm([]string{"2", "##start", "s 0 0", "a 1 0", "b 1 1", "##end", "e 2 0", "s-a", "a-e", "s-b", "b-a"})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	turns := [][]string{{"L1-a", "L2-b"}, {"L2-a"}, {}, {"L1-e"}, {"L2-e"}}
+	var got []string
+	for _, v := range VerifyMoves(farm, turns) {
+		got = append(got, v.String())
+	}
+
+	want := []string{"turn 2: room a holds more than one ant", "turn 3: room a holds more than one ant"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("VerifyMoves reported %q, expected %q", got, want)
+	}
+}
+
+// TestVerifyMovesWithRulesContext tests that verifying stops once the context is done
+func TestVerifyMovesWithRulesContext(t *testing.T) {
+	farm, err := BuildFarm([]string{"1", "##start", "s 0 0", "##end", "e 1 0", "s-e"})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := VerifyMovesWithRulesContext(ctx, farm, [][]string{{"L1-e"}}, ClassicRules); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected canceled error, got %v", err)
+	}
+}
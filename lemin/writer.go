@@ -0,0 +1,102 @@
+package lemin
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteTo writes the farm in lem-in format: the number of ants,
+// every room in definition order (preceded by ##start or ##end when needed),
+// then every tunnel. Comments and unknown commands are written back just
+// before the room or tunnel they belong to. Parsing the result gives back
+// an equal farm: when a tunnel would be ambiguous once every room is defined,
+// which lenient parsing allows, each tunnel is instead written right after
+// the later of its two rooms.
+func (f *Farm) WriteTo(w io.Writer) (int64, error) {
+	return writeFarm(w, f, true)
+}
+
+// WriteCanonical writes the farm like WriteTo but leaves out all comments
+// and unknown commands
+func (f *Farm) WriteCanonical(w io.Writer) (int64, error) {
+	return writeFarm(w, f, false)
+}
+
+// writeFarm builds the lem-in text of the farm and writes it in one go
+func writeFarm(w io.Writer, f *Farm, withAnnotations bool) (int64, error) {
+	var sb strings.Builder
+
+	// writeAnnotations copies comment lines when they are kept
+	writeAnnotations := func(annotations []string) {
+		if !withAnnotations {
+			return
+		}
+		for _, line := range annotations {
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	fmt.Fprintln(&sb, f.antCount)
+
+	// writeTunnel writes one tunnel with its comments
+	writeTunnel := func(tunnel *Tunnel) {
+		writeAnnotations(tunnel.annotations)
+		fmt.Fprintf(&sb, "%s-%s\n", tunnel.from.name, tunnel.to.name)
+	}
+
+	// Tunnels that must follow each room, only when some tunnel is ambiguous
+	after := tunnelsAfterRooms(f)
+
+	for _, room := range f.order {
+		writeAnnotations(room.annotations)
+		if room == f.start {
+			sb.WriteString("##start\n")
+		}
+		if room == f.end {
+			sb.WriteString("##end\n")
+		}
+		fmt.Fprintf(&sb, "%s %d %d\n", room.name, room.x, room.y)
+		for _, tunnel := range after[room] {
+			writeTunnel(tunnel)
+		}
+	}
+
+	if after == nil {
+		for _, tunnel := range f.tunnels {
+			writeTunnel(tunnel)
+		}
+	}
+
+	writeAnnotations(f.annotations)
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// tunnelsAfterRooms returns nil when every tunnel can be written after all
+// the rooms. Otherwise it groups the tunnels by the later of their two rooms:
+// the rooms defined up to there are some of those defined when the tunnel
+// was first parsed, so the tunnel can't be ambiguous at that point.
+func tunnelsAfterRooms(f *Farm) map[*Room][]*Tunnel {
+	ambiguous := false
+	for _, tunnel := range f.tunnels {
+		if _, _, err := splitLink(tunnel.from.name+"-"+tunnel.to.name, f); err != nil {
+			ambiguous = true
+			break
+		}
+	}
+	if !ambiguous {
+		return nil
+	}
+
+	after := make(map[*Room][]*Tunnel)
+	for _, tunnel := range f.tunnels {
+		later := tunnel.from
+		if tunnel.to.id > later.id {
+			later = tunnel.to
+		}
+		after[later] = append(after[later], tunnel)
+	}
+	return after
+}
@@ -0,0 +1,89 @@
+package lemin
+
+import (
+	"strings"
+	"testing"
+)
+
+// writerInput is a messy but valid farm used by the writer tests
+var writerInput = []string{
+	"2",
+	"# the entrance",
+	"##start",
+	"s   0 0",
+	"##color red",
+	"a 1 +1",
+	"s-a",
+	"##end",
+	"e 2 0",
+	"# last tunnel",
+	"a-e",
+	"e-a", // Duplicate tunnel
+	"# the end",
+}
+
+// TestFarmWriteTo tests that a farm is written back with its comments in place
+func TestFarmWriteTo(t *testing.T) {
+	farm, err := BuildFarm(writerInput)
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	var sb strings.Builder
+	n, err := farm.WriteTo(&sb)
+	if err != nil {
+		t.Fatalf("WriteTo returned error: %v", err)
+	}
+
+	want := "2\n# the entrance\n##start\ns 0 0\n##color red\na 1 1\n##end\ne 2 0\ns-a\n# last tunnel\na-e\n# the end\n"
+	if sb.String() != want {
+		t.Errorf("WriteTo wrote:\n%s\nwant:\n%s", sb.String(), want)
+	}
+	if n != int64(len(want)) {
+		t.Errorf("WriteTo returned %d bytes, wrote %d", n, len(want))
+	}
+}
+
+// TestFarmWriteCanonical tests that the canonical format leaves out comments
+func TestFarmWriteCanonical(t *testing.T) {
+	farm, err := BuildFarm(writerInput)
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	var sb strings.Builder
+	if _, err := farm.WriteCanonical(&sb); err != nil {
+		t.Fatalf("WriteCanonical returned error: %v", err)
+	}
+
+	want := "2\n##start\ns 0 0\na 1 1\n##end\ne 2 0\ns-a\na-e\n"
+	if sb.String() != want {
+		t.Errorf("WriteCanonical wrote:\n%s\nwant:\n%s", sb.String(), want)
+	}
+}
+
+// TestFarmWriteTo_AmbiguousLink tests that a tunnel parsed before a clashing
+// room was defined is written where it still reads the same
+func TestFarmWriteTo_AmbiguousLink(t *testing.T) {
+	farm, err := BuildFarm([]string{"1", "##start", "a 0 0", "##end", "b-c 1 0", "a-b-c", "a-b 2 0", "c 3 0", "c-a-b"})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	var sb strings.Builder
+	if _, err := farm.WriteTo(&sb); err != nil {
+		t.Fatalf("WriteTo returned error: %v", err)
+	}
+
+	want := "1\n##start\na 0 0\n##end\nb-c 1 0\na-b-c\na-b 2 0\nc 3 0\nc-a-b\n"
+	if sb.String() != want {
+		t.Errorf("WriteTo wrote:\n%s\nwant:\n%s", sb.String(), want)
+	}
+	again, err := BuildFarm(ParseInput(sb.String()))
+	if err != nil {
+		t.Fatalf("re-parsing failed: %v", err)
+	}
+	if diff := compareFarms(farm, again); diff != "" {
+		t.Errorf("farm changed after round trip: %s", diff)
+	}
+}
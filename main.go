@@ -1,21 +1,145 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
+
+	"github.com/nido007/Lem-in-visual/lemin"
 )
 
+// config holds the command-line settings for a run
+type config struct {
+	parse    lemin.ParseOptions // How strictly the farm is checked
+	rules    lemin.Rules        // Movement rules for solving, simulating and verifying
+	ties     []TieBreaker       // How to choose between plans with the same turns, in order
+	warnings io.Writer          // Where parser warnings go, nil to drop them
+	maxBody  int64              // Largest request body the server accepts, in bytes
+	timeout  time.Duration      // Longest the server spends solving one request
+	limits   solveLimits        // Largest farms the server solves, and how many at once
+	json     bool               // Print the solution as a JSON trace instead of lem-in text
+	stats    io.Writer          // Where the statistics report goes, nil for none
+	heatmap  io.Writer          // Where the room and tunnel heatmap table goes, nil for none
+	explain  io.Writer          // Where the explanation of the path choice goes, nil for none
+
+	alternatives     io.Writer // Where the best plans are listed side by side, nil for none
+	alternativeCount int       // How many of the best plans to list
+
+	heatmapCSV string // Where to write the heatmap as CSV, if set
+
+	gifPath   string        // Where to write an animated GIF of the simulation, if set
+	framesDir string        // Where to write one PNG per turn, if set
+	render    RenderOptions // Size, delay and colors of the images
+}
+
+// defaultConfig returns the settings used when no flags are given
+func defaultConfig() config {
+	return config{
+		parse:   lemin.DefaultParseOptions(),
+		rules:   lemin.ClassicRules,
+		maxBody: 1 << 20,
+		timeout: 10 * time.Second,
+		limits:  defaultSolveLimits(),
+		render:  DefaultRenderOptions(),
+	}
+}
+
 // main is the entry point: it reads input file, constructs the farm, finds paths, and simulates ant movements.
 func main() {
+	// Subcommands come before the usual arguments
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		runServe(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "debug" {
+		runDebug(os.Args[2:])
+		return
+	}
+
+	defaults := defaultConfig()
+
+	strict := flag.Bool("strict", false, "reject input that doesn't follow the canonical lem-in order")
+	rules := flag.String("rules", defaults.rules.Name, "movement rules: classic, strict or relaxed")
+	tieBreak := flag.String("tie-break", "", "comma-separated tie-breakers between plans with the same turns: moves, paths, load, avoid")
+	avoid := flag.String("avoid", "", "comma-separated rooms to steer ants away from, besides ##avoid rooms (implies --tie-break avoid)")
+	gifPath := flag.String("gif", "", "also write an animated GIF of the simulation to this file")
+	framesDir := flag.String("frames", "", "also write one PNG per turn into this directory")
+	size := flag.String("size", fmt.Sprintf("%dx%d", defaults.render.Width, defaults.render.Height), "image size as WIDTHxHEIGHT")
+	delay := flag.Duration("delay", defaults.render.Delay, "how long each turn is shown in the GIF")
+	pathColors := flag.Bool("path-colors", defaults.render.PathColors, "draw each path and its ants in their own color")
+	stats := flag.Bool("stats", false, "print per-ant and per-path statistics to stderr after the moves")
+	alternatives := flag.Int("alternatives", 0, "print the K best plans side by side to stderr before the moves")
+	explain := flag.Bool("explain", false, "print why the solver chose its paths to stderr before the moves")
+	heatmap := flag.Bool("heatmap", false, "print how busy every room and tunnel was to stderr after the moves")
+	heatmapCSV := flag.String("heatmap-csv", "", "also write the room and tunnel heatmap as CSV to this file")
+	jsonTrace := flag.Bool("json", false, "print the farm, paths and moves as a JSON trace for the visualizer")
+	warnings := flag.Bool("warnings", false, "print what lenient parsing let through to stderr")
+	flag.Parse()
+
 	// Check if user provided exactly one argument (the filename)
-	if len(os.Args) != 2 {
-		fmt.Println("ERROR: usage --> go run . <filename>")
+	if flag.NArg() != 1 {
+		fmt.Println("ERROR: usage --> go run . [--strict] [--warnings] [--rules name] [--tie-break list] [--avoid rooms] [--json] [--stats] [--explain] [--alternatives K] [--heatmap] [--heatmap-csv file] [--gif file] [--frames dir] [--size WxH] [--delay 500ms] <filename>")
 		fmt.Println("For visualization: ./lem-in <filename> | ./visualizer")
+		fmt.Println("In the browser: ./lem-in serve <filename>")
+		fmt.Println("Step through a run: ./lem-in debug <filename>")
 		return
 	}
 
-	filename := os.Args[1]
+	filename := flag.Arg(0)
+
+	cfg := defaults
+	cfg.parse.Strict = *strict
+	if *warnings {
+		cfg.warnings = os.Stderr
+	}
+	ruleSet, err := lemin.RulesByName(*rules)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	cfg.rules = ruleSet
+	cfg.ties, err = ParseTieBreakers(*tieBreak, *avoid)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	cfg.gifPath = *gifPath
+	cfg.framesDir = *framesDir
+	cfg.json = *jsonTrace
+	if *stats {
+		cfg.stats = os.Stderr
+	}
+	if *heatmap {
+		cfg.heatmap = os.Stderr
+	}
+	if *explain {
+		cfg.explain = os.Stderr
+	}
+	if *alternatives < 0 {
+		fmt.Println("ERROR: --alternatives needs a positive number of plans")
+		return
+	}
+	if *alternatives > 0 {
+		cfg.alternatives = os.Stderr
+		cfg.alternativeCount = *alternatives
+	}
+	cfg.heatmapCSV = *heatmapCSV
+	cfg.render.Delay = *delay
+	cfg.render.PathColors = *pathColors
+	if _, err := fmt.Sscanf(*size, "%dx%d", &cfg.render.Width, &cfg.render.Height); err != nil {
+		fmt.Println("ERROR: invalid image size:", *size)
+		return
+	}
+	if err := cfg.render.Validate(); err != nil {
+		fmt.Println(err)
+		return
+	}
 
 	// Read the input file
 	content, err := os.ReadFile(filename)
@@ -24,38 +148,183 @@ func main() {
 		return
 	}
 
+	// Solve the farm and print the result
+	if err := run(os.Stdout, string(content), cfg); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// writeWarnings prints what lenient parsing let through, one line each, unless w is nil
+func writeWarnings(w io.Writer, farm *lemin.Farm) {
+	if w == nil {
+		return
+	}
+	for _, warning := range farm.Warnings() {
+		fmt.Fprintln(w, "WARNING:", warning)
+	}
+}
+
+// run solves the farm described by content and writes the lem-in output to w:
+// the original input, a blank line, then the moves of each turn. With cfg.json
+// the same is written as a lemin.Solution instead.
+// Nothing is written if the farm is invalid.
+func run(w io.Writer, content string, cfg config) error {
 	// Parse the file content into lines
-	lines := parseInput(string(content))
+	lines := lemin.ParseInput(content)
 
 	// Build the farm structure from the parsed lines
-	farm, err := BuildFarm(lines)
+	farm, err := lemin.BuildFarmWithOptions(lines, cfg.parse)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
+	}
+	writeWarnings(cfg.warnings, farm)
+
+	// Find the paths the ants will take
+	best, err := solveFarm(farm, cfg.rules, cfg.ties...)
+	if err != nil {
+		return err
+	}
+	if cfg.explain != nil {
+		if err := explainChoice(cfg.explain, farm, best, cfg.rules, cfg.ties); err != nil {
+			return err
+		}
+	}
+	if cfg.alternatives != nil {
+		plans := alternativePlans(farm, best, cfg.alternativeCount, cfg.rules, cfg.ties...)
+		if err := WriteAlternatives(cfg.alternatives, farm.AntCount(), cfg.rules, plans); err != nil {
+			return err
+		}
+	}
+
+	// Run the ant movement simulation
+	turns := RunSimulationWithRules(farm, best.Paths, cfg.rules)
+
+	if cfg.json {
+		if err := json.NewEncoder(w).Encode(lemin.NewSolution(farm, best.Paths, turns)); err != nil {
+			return err
+		}
+	} else {
+		// Echo original input first (as required by the project)
+		fmt.Fprint(w, content)
+		// Add blank line only if content doesn't end with newline
+		if !strings.HasSuffix(content, "\n") {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w)
+
+		PrintTurns(w, turns)
+	}
+
+	if cfg.stats != nil {
+		if err := ComputeStats(farm, best.Paths, turns).WriteReport(cfg.stats); err != nil {
+			return err
+		}
+	}
+
+	if err := writeHeatmap(farm, turns, cfg); err != nil {
+		return err
+	}
+
+	return renderImages(farm, best.Paths, turns, cfg)
+}
+
+// writeHeatmap prints the heatmap table and writes the CSV asked for on the command line
+func writeHeatmap(farm *lemin.Farm, turns [][]string, cfg config) error {
+	if cfg.heatmap == nil && cfg.heatmapCSV == "" {
+		return nil
+	}
+	heat := lemin.ComputeHeatmap(farm, turns)
+
+	if cfg.heatmap != nil {
+		if err := heat.WriteTable(cfg.heatmap); err != nil {
+			return err
+		}
+	}
+
+	if cfg.heatmapCSV != "" {
+		file, err := os.Create(cfg.heatmapCSV)
+		if err != nil {
+			return fmt.Errorf("ERROR: could not create heatmap CSV: %w", err)
+		}
+		err = heat.WriteCSV(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("ERROR: could not write heatmap CSV: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// renderImages writes the GIF and PNG frames asked for on the command line
+func renderImages(farm *lemin.Farm, paths [][]*lemin.Room, turns [][]string, cfg config) error {
+	if cfg.gifPath != "" {
+		file, err := os.Create(cfg.gifPath)
+		if err != nil {
+			return fmt.Errorf("ERROR: could not create GIF: %w", err)
+		}
+		err = RenderGIF(file, farm, paths, turns, cfg.render)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("ERROR: could not write GIF: %w", err)
+		}
+	}
+
+	if cfg.framesDir != "" {
+		if err := RenderFrames(cfg.framesDir, farm, paths, turns, cfg.render); err != nil {
+			return fmt.Errorf("ERROR: could not write frames: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// explainChoice writes why the paths in best were chosen
+func explainChoice(w io.Writer, farm *lemin.Farm, best PathCombination, rules lemin.Rules, ties []TieBreaker) error {
+	if rules.DirectUnlimited && lemin.IsLinked(farm.Start(), farm.End()) {
+		_, err := fmt.Fprintf(w, "Explain: %s rules send all %d ants through the %s-%s tunnel in 1 turn\n\n",
+			rules.Name, farm.AntCount(), farm.Start().Name(), farm.End().Name())
+		return err
+	}
+	return ExplainPathCombination(farm.AntCount(), FindAllPaths(farm), ties...).WriteReport(w)
+}
+
+// solveFarm finds the combination of paths that gets all ants to the end fastest
+// under the given rules, using ties to choose between equally fast ones
+func solveFarm(farm *lemin.Farm, rules lemin.Rules, ties ...TieBreaker) (PathCombination, error) {
+	return solveFarmContext(context.Background(), farm, rules, 0, ties...)
+}
+
+// solveFarmContext is solveFarm that stops with the context's error once ctx
+// is done, or with ErrTooManyPaths when the farm has more than maxPaths paths
+// (0 for no limit)
+func solveFarmContext(ctx context.Context, farm *lemin.Farm, rules lemin.Rules, maxPaths int, ties ...TieBreaker) (PathCombination, error) {
+	// A start-end tunnel that carries every ant at once can't be beaten
+	if rules.DirectUnlimited && lemin.IsLinked(farm.Start(), farm.End()) {
+		return PathCombination{Paths: [][]*lemin.Room{{farm.Start(), farm.End()}}, Turns: 1, Moves: farm.AntCount()}, nil
 	}
 
 	// Find all possible paths from start to end
-	allPaths := FindAllPaths(farm.Start, farm.End)
+	allPaths, err := FindAllPathsContext(ctx, farm, maxPaths)
+	if err != nil {
+		return PathCombination{}, err
+	}
 	if len(allPaths) == 0 {
-		fmt.Println("ERROR: invalid data format, no path from ##start to ##end")
-		return
+		return PathCombination{}, errors.New("ERROR: invalid data format, no path from ##start to ##end")
 	}
 
 	// Find the best combination of paths that minimizes total moves
-	best := FindOptimalPathCombination(farm.AntCount, allPaths)
-	if len(best.Paths) == 0 {
-		fmt.Println("ERROR: invalid data format, no valid path combination found")
-		return
+	best, err := FindOptimalPathCombinationContext(ctx, farm.AntCount(), allPaths, ties...)
+	if err != nil {
+		return PathCombination{}, err
 	}
-
-	// Echo original input first (as required by the project)
-	fmt.Print(string(content))
-	// Add blank line only if content doesn't end with newline
-	if !strings.HasSuffix(string(content), "\n") {
-		fmt.Println()
+	if len(best.Paths) == 0 {
+		return PathCombination{}, errors.New("ERROR: invalid data format, no valid path combination found")
 	}
-	fmt.Println()
 
-	// Run the ant movement simulation
-	RunSimulation(farm, best.Paths)
+	return best, nil
 }
@@ -1,9 +1,20 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"strings"
+)
 
 // PrintAntMove creates the string format for an ant move
 // This creates strings like "L1-room2" meaning "Ant 1 moves to room2"
 func PrintAntMove(antID int, roomName string) string {
 	return fmt.Sprintf("L%d-%s", antID, roomName)
 }
+
+// PrintTurns writes the moves of each turn on its own line
+func PrintTurns(w io.Writer, turns [][]string) {
+	for _, moves := range turns {
+		fmt.Fprintln(w, strings.Join(moves, " "))
+	}
+}
@@ -1,112 +1,276 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"slices"
 	"sort"
+
+	"github.com/nido007/Lem-in-visual/lemin"
 )
 
-// FindAllPaths finds every possible route from start to end
-func FindAllPaths(start, end *Room) [][]*Room {
-	var result [][]*Room
+// ErrTooManyPaths is returned when a farm has more paths than the caller allows
+var ErrTooManyPaths = errors.New("ERROR: too many paths from ##start to ##end")
+
+// ErrUnbuiltFarm is returned for a farm that didn't come from lemin.BuildFarm.
+// The solver and the simulator work on the farm's Graph and the room IDs it
+// sets, which zero Farm and Room values don't have.
+var ErrUnbuiltFarm = errors.New("ERROR: farm has no graph or room IDs, build it with lemin.BuildFarm")
+
+// cancelCheck tells the searches when to give up. It only looks at the
+// context every 1024 calls, since it is called in their innermost loops.
+type cancelCheck struct {
+	ctx   context.Context
+	calls int
+}
+
+// err returns the context's error once it is done
+func (c *cancelCheck) err() error {
+	c.calls++
+	if c.calls%1024 != 0 {
+		return nil
+	}
+	return c.ctx.Err()
+}
+
+// FindAllPaths finds every possible route from start to end. The farm must
+// come from lemin.BuildFarm; FindAllPaths panics with ErrUnbuiltFarm otherwise.
+func FindAllPaths(farm *lemin.Farm) [][]*lemin.Room {
+	paths, err := FindAllPathsContext(context.Background(), farm, 0)
+	if err != nil {
+		panic(err) // Nothing else can fail without a deadline or limit
+	}
+	return paths
+}
+
+// FindAllPathsContext is FindAllPaths that stops with the context's error
+// once ctx is done, or with ErrTooManyPaths once it finds more than maxPaths
+// paths. A maxPaths of 0 means no limit.
+func FindAllPathsContext(ctx context.Context, farm *lemin.Farm, maxPaths int) ([][]*lemin.Room, error) {
+	g := farm.Graph()
+	if g == nil {
+		return nil, ErrUnbuiltFarm
+	}
+	var result [][]*lemin.Room
+	check := &cancelCheck{ctx: ctx}
+
+	// Use depth-first search on room IDs to explore all paths
+	path := []int32{g.Start()}
+	visited := lemin.NewBitset(g.NumRooms())
+	visited.Set(int(g.Start()))
 
-	// Use depth-first search to explore all paths
-	var dfs func(path []*Room, visited map[string]bool)
-	dfs = func(path []*Room, visited map[string]bool) {
+	var dfs func() error
+	dfs = func() error {
+		if err := check.err(); err != nil {
+			return err
+		}
 		current := path[len(path)-1]
 
-		// If we reached the end, save this path
-		if current == end {
-			// Make a copy so we don't modify the original
-			copyPath := make([]*Room, len(path))
-			copy(copyPath, path)
-			result = append(result, copyPath)
-			return
+		// If we reached the end, save this path as rooms
+		if current == g.End() {
+			if maxPaths > 0 && len(result) == maxPaths {
+				return ErrTooManyPaths
+			}
+			rooms := make([]*lemin.Room, len(path))
+			for i, id := range path {
+				rooms[i] = g.Room(id)
+			}
+			result = append(result, rooms)
+			return nil
 		}
 
 		// Try all connected rooms
-		for _, neighbor := range current.Links {
+		for neighbor := range g.Neighbors(current) {
 			// Don't revisit rooms we've already been to
-			if visited[neighbor.Name] {
+			if visited.Has(int(neighbor)) {
 				continue
 			}
 
 			// Mark this room as visited and continue exploring
-			visited[neighbor.Name] = true
-			dfs(append(path, neighbor), visited)
-			visited[neighbor.Name] = false // Backtrack
+			visited.Set(int(neighbor))
+			path = append(path, neighbor)
+			if err := dfs(); err != nil {
+				return err
+			}
+			path = path[:len(path)-1]
+			visited.Unset(int(neighbor)) // Backtrack
 		}
+		return nil
+	}
+	if err := dfs(); err != nil {
+		return nil, err
 	}
 
-	// Start the search from the starting room
-	visited := map[string]bool{start.Name: true}
-	dfs([]*Room{start}, visited)
+	// Order paths so the result doesn't depend on link insertion order
+	sort.SliceStable(result, func(i, j int) bool {
+		return lessPath(result[i], result[j])
+	})
 
-	return result
+	return result, nil
 }
 
-// isCompatible checks if a path can be used alongside other paths
-// Two paths are compatible if they don't share any middle rooms
-func isCompatible(candidate []*Room, currentSet [][]*Room) bool {
-	// Track which middle rooms are already used
-	used := make(map[string]bool)
-	for _, path := range currentSet {
-		// Only check middle rooms (skip first and last)
-		for i := 1; i < len(path)-1; i++ {
-			used[path[i].Name] = true
+// lessPath defines the tie-breaking order between two paths:
+// shorter paths first, then by comparing room names one by one
+func lessPath(a, b []*lemin.Room) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	for i := range a {
+		if a[i].Name() != b[i].Name() {
+			return a[i].Name() < b[i].Name()
 		}
 	}
+	return false
+}
 
-	// Check if candidate path uses any already-used middle rooms
-	for i := 1; i < len(candidate)-1; i++ {
-		if used[candidate[i].Name] {
+// isCompatible checks if a path can be used alongside the paths whose
+// middle rooms are in used. Two paths are compatible if they don't share
+// any middle rooms. Rooms are told apart by ID alone.
+func isCompatible(candidate []*lemin.Room, used lemin.Bitset) bool {
+	// Only check middle rooms (skip first and last)
+	for _, room := range candidate[1 : len(candidate)-1] {
+		if used.Has(room.ID()) {
 			return false // Conflict found
 		}
 	}
-
 	return true // No conflicts
 }
 
-// FindNonOverlappingPathSets finds all possible combinations of paths that don't interfere
-func FindNonOverlappingPathSets(paths [][]*Room) [][][]*Room {
-	var result [][][]*Room
+// markMiddleRooms adds the middle rooms of path to used, or removes them
+func markMiddleRooms(path []*lemin.Room, used lemin.Bitset, add bool) {
+	for _, room := range path[1 : len(path)-1] {
+		if add {
+			used.Set(room.ID())
+		} else {
+			used.Unset(room.ID())
+		}
+	}
+}
+
+// FindNonOverlappingPathSets finds all possible combinations of paths that
+// don't interfere. The rooms must carry the IDs lemin.BuildFarm gives them, as
+// in the paths from FindAllPaths; it panics with ErrUnbuiltFarm otherwise.
+func FindNonOverlappingPathSets(paths [][]*lemin.Room) [][][]*lemin.Room {
+	sets, err := FindNonOverlappingPathSetsContext(context.Background(), paths)
+	if err != nil {
+		panic(err) // Nothing else can fail without a deadline
+	}
+	return sets
+}
+
+// FindNonOverlappingPathSetsContext is FindNonOverlappingPathSets that stops
+// with the context's error once ctx is done, or with ErrUnbuiltFarm when two
+// rooms share an ID
+func FindNonOverlappingPathSetsContext(ctx context.Context, paths [][]*lemin.Room) ([][][]*lemin.Room, error) {
+	var result [][][]*lemin.Room
+	err := forEachPathSet(ctx, paths, func(set [][]*lemin.Room) {
+		result = append(result, slices.Clone(set))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// forEachPathSet calls fn with every combination of non-overlapping paths,
+// the empty one first, in the order FindNonOverlappingPathSets lists them.
+// The slice given to fn is reused for the next combination, so searches
+// that only keep a few combinations don't hold them all in memory.
+func forEachPathSet(ctx context.Context, paths [][]*lemin.Room, fn func(set [][]*lemin.Room)) error {
+	check := &cancelCheck{ctx: ctx}
+
+	// Room IDs are dense, so the largest one bounds the set of used rooms
+	rooms := 0
+	for _, path := range paths {
+		for _, room := range path {
+			rooms = max(rooms, room.ID()+1)
+		}
+	}
+	byID := make([]*lemin.Room, rooms)
+	for _, path := range paths {
+		for _, room := range path {
+			if byID[room.ID()] != nil && byID[room.ID()] != room {
+				return ErrUnbuiltFarm
+			}
+			byID[room.ID()] = room
+		}
+	}
+	used := lemin.NewBitset(rooms)
 
 	// Use backtracking to find all valid combinations
-	var backtrack func(start int, currentSet [][]*Room)
-	backtrack = func(start int, currentSet [][]*Room) {
-		// Save current combination (even if empty)
-		copySet := make([][]*Room, len(currentSet))
-		copy(copySet, currentSet)
-		result = append(result, copySet)
+	var backtrack func(start int, currentSet [][]*lemin.Room) error
+	backtrack = func(start int, currentSet [][]*lemin.Room) error {
+		if err := check.err(); err != nil {
+			return err
+		}
+
+		// Report current combination (even if empty)
+		fn(currentSet)
 
 		// Try adding more paths
 		for i := start; i < len(paths); i++ {
-			if isCompatible(paths[i], currentSet) {
-				backtrack(i+1, append(currentSet, paths[i]))
+			if isCompatible(paths[i], used) {
+				markMiddleRooms(paths[i], used, true)
+				if err := backtrack(i+1, append(currentSet, paths[i])); err != nil {
+					return err
+				}
+				markMiddleRooms(paths[i], used, false)
 			}
 		}
+		return nil
 	}
 
-	backtrack(0, [][]*Room{})
-	return result
+	return backtrack(0, [][]*lemin.Room{})
 }
 
 // PathCombination represents a set of paths and how many turns they'll take
 type PathCombination struct {
-	Paths [][]*Room
+	Paths [][]*lemin.Room
 	Turns int
+	Moves int // Total ant moves, see TotalMoves
 }
 
 // EstimateTurns calculates how many moves it will take to get all ants through
-func EstimateTurns(antCount int, paths [][]*Room) int {
+func EstimateTurns(antCount int, paths [][]*lemin.Room) int {
+	return EstimateTurnsWithRules(antCount, paths, lemin.ClassicRules)
+}
+
+// EstimateTurnsWithRules estimates the turns like EstimateTurns for a run under
+// the given rules. When a start-end tunnel carries any number of ants, a direct
+// path takes all of its ants through in the first turn.
+func EstimateTurnsWithRules(antCount int, paths [][]*lemin.Room, rules lemin.Rules) int {
 	if len(paths) == 0 {
 		return 999999 // Infinity - no paths available
 	}
 
-	// Sort paths by length (shortest first)
-	sort.Slice(paths, func(i, j int) bool {
-		return len(paths[i]) < len(paths[j])
+	// Sort paths by length (shortest first), breaking ties by room names
+	sort.SliceStable(paths, func(i, j int) bool {
+		return lessPath(paths[i], paths[j])
 	})
+	antsPerPath := DistributeAnts(antCount, paths)
 
-	// Distribute ants among paths
+	// Calculate maximum turns among all paths
+	maxTurns := 0
+	for i, path := range paths {
+		if antsPerPath[i] == 0 {
+			continue
+		}
+		// Time = path length + time for all ants to go through
+		turns := (len(path) - 1) + (antsPerPath[i] - 1)
+		if rules.DirectUnlimited && len(path) == 2 {
+			turns = 1
+		}
+		if turns > maxTurns {
+			maxTurns = turns
+		}
+	}
+
+	return maxTurns
+}
+
+// DistributeAnts returns how many ants EstimateTurns gives each path,
+// for paths already in EstimateTurns order
+func DistributeAnts(antCount int, paths [][]*lemin.Room) []int {
 	antsLeft := antCount
 	antsPerPath := make([]int, len(paths))
 
@@ -121,43 +285,127 @@ func EstimateTurns(antCount int, paths [][]*Room) int {
 		}
 	}
 
-	// Calculate maximum turns among all paths
-	maxTurns := 0
-	for i, path := range paths {
-		if antsPerPath[i] == 0 {
-			continue
+	return antsPerPath
+}
+
+// TotalMoves returns how many moves all ants make in total when EstimateTurns
+// spreads them over paths, which must already be in EstimateTurns order
+func TotalMoves(antCount int, paths [][]*lemin.Room) int {
+	total := 0
+	for i, ants := range DistributeAnts(antCount, paths) {
+		total += ants * (len(paths[i]) - 1)
+	}
+	return total
+}
+
+// FindTopPathCombinations returns up to k combinations of non-overlapping
+// paths, fewest turns under rules first, then by the tie-breakers, then
+// fewest total moves. Combinations that tie on all of them keep the order
+// FindOptimalPathCombination considers them in.
+func FindTopPathCombinations(antCount int, paths [][]*lemin.Room, k int, rules lemin.Rules, ties ...TieBreaker) []PathCombination {
+	if k <= 0 {
+		return nil
+	}
+
+	// Only the best k are kept, in order, as the combinations come in
+	var top []PathCombination
+	var topScores [][]int
+	searchPathCombinations(context.Background(), antCount, paths, rules, nil, func(combo [][]*lemin.Room, turns int, best bool) {
+		c := PathCombination{Paths: combo, Turns: turns, Moves: TotalMoves(antCount, combo)}
+		scores := scoreCombination(antCount, combo, ties)
+
+		// Go after every kept combination that isn't worse, so ties keep their order
+		i := sort.Search(len(top), func(i int) bool {
+			if c.Turns != top[i].Turns {
+				return c.Turns < top[i].Turns
+			}
+			if cmp := slices.Compare(scores, topScores[i]); cmp != 0 {
+				return cmp < 0
+			}
+			return c.Moves < top[i].Moves
+		})
+		if i == k {
+			return
 		}
-		// Time = path length + time for all ants to go through
-		turns := (len(path) - 1) + (antsPerPath[i] - 1)
-		if turns > maxTurns {
-			maxTurns = turns
+		top = slices.Insert(top, i, c)
+		topScores = slices.Insert(topScores, i, scores)
+		if len(top) > k {
+			top, topScores = top[:k], topScores[:k]
 		}
-	}
+	})
+	return top
+}
 
-	return maxTurns
+// FindOptimalPathCombination finds the best combination of paths. Among
+// combinations with the fewest turns the tie-breakers pick, in order, and
+// the first one considered wins any tie they leave.
+func FindOptimalPathCombination(antCount int, paths [][]*lemin.Room, ties ...TieBreaker) PathCombination {
+	best, _ := searchPathCombinations(context.Background(), antCount, paths, lemin.ClassicRules, ties, nil)
+	return best
 }
 
-// FindOptimalPathCombination finds the best combination of paths
-func FindOptimalPathCombination(antCount int, paths [][]*Room) PathCombination {
-	combinations := FindNonOverlappingPathSets(paths)
+// FindOptimalPathCombinationContext is FindOptimalPathCombination that stops
+// with the context's error once ctx is done
+func FindOptimalPathCombinationContext(ctx context.Context, antCount int, paths [][]*lemin.Room, ties ...TieBreaker) (PathCombination, error) {
+	return searchPathCombinations(ctx, antCount, paths, lemin.ClassicRules, ties, nil)
+}
 
+// searchPathCombinations estimates every combination of non-overlapping paths
+// under rules and keeps the first one with the fewest turns, or the lowest tie-breaker
+// scores among those. Combinations are estimated as they are found, so only
+// the best one is held in memory. visit, when not nil, is called for every
+// combination with its turns and whether it became the best so far, and may
+// keep combo. It stops with the context's error once ctx is done.
+func searchPathCombinations(ctx context.Context, antCount int, paths [][]*lemin.Room, rules lemin.Rules, ties []TieBreaker, visit func(combo [][]*lemin.Room, turns int, best bool)) (PathCombination, error) {
 	var best PathCombination
+	var bestScores []int
 	best.Turns = 999999 // Start with worst case
 
 	// Test each combination and keep the best one
-	for _, combo := range combinations {
-		if len(combo) == 0 {
-			continue // Skip empty combinations
+	var scratch [][]*lemin.Room
+	err := forEachPathSet(ctx, paths, func(set [][]*lemin.Room) {
+		if len(set) == 0 {
+			return // Skip empty combinations
 		}
 
-		turns := EstimateTurns(antCount, combo)
-		if turns < best.Turns {
+		// EstimateTurns sorts the paths, so work on a copy of the set
+		scratch = append(scratch[:0], set...)
+		combo := scratch
+		turns := EstimateTurnsWithRules(antCount, combo, rules)
+		improved := turns < best.Turns
+		var scores []int
+		if turns <= best.Turns && len(ties) > 0 {
+			scores = scoreCombination(antCount, combo, ties)
+			improved = improved || slices.Compare(scores, bestScores) < 0
+		}
+		if improved || visit != nil {
+			combo = slices.Clone(combo)
+		}
+		if improved {
 			best = PathCombination{
 				Paths: combo,
 				Turns: turns,
+				Moves: TotalMoves(antCount, combo),
 			}
+			bestScores = scores
+		}
+		if visit != nil {
+			visit(combo, turns, improved)
 		}
+	})
+	if err != nil {
+		return PathCombination{}, err
 	}
 
-	return best
+	return best, nil
+}
+
+// scoreCombination rates a combination, already in EstimateTurns order, with
+// each tie-breaker
+func scoreCombination(antCount int, combo [][]*lemin.Room, ties []TieBreaker) []int {
+	scores := make([]int, len(ties))
+	for i, tie := range ties {
+		scores[i] = tie.Score(antCount, combo)
+	}
+	return scores
 }
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// pathNames turns a list of paths into comparable strings
+func pathNames(paths [][]*lemin.Room) []string {
+	var names []string
+	for _, path := range paths {
+		var parts []string
+		for _, room := range path {
+			parts = append(parts, room.Name())
+		}
+		names = append(names, strings.Join(parts, "-"))
+	}
+	return names
+}
+
+// TestFindAllPaths_Deterministic tests that link order doesn't change the path order
+func TestFindAllPaths_Deterministic(t *testing.T) {
+	rooms := []string{"3", "##start", "s 0 0", "b 1 0", "a 1 1", "c 2 0", "##end", "e 3 0"}
+	links := []string{"s-b", "s-a", "b-e", "a-e", "s-c", "c-b"}
+
+	var reversed []string
+	for i := len(links) - 1; i >= 0; i-- {
+		reversed = append(reversed, links[i])
+	}
+
+	farm1, err := lemin.BuildFarm(append(append([]string{}, rooms...), links...))
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+	farm2, err := lemin.BuildFarm(append(append([]string{}, rooms...), reversed...))
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	got1 := pathNames(FindAllPaths(farm1))
+	got2 := pathNames(FindAllPaths(farm2))
+	want := []string{"s-a-e", "s-b-e", "s-c-b-e"}
+
+	if strings.Join(got1, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected paths %v, got %v", want, got1)
+	}
+	if strings.Join(got2, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected paths %v with reversed links, got %v", want, got2)
+	}
+}
+
+// TestEstimateTurns_StableOrder tests that equal-length paths are ordered by room names
+func TestEstimateTurns_StableOrder(t *testing.T) {
+	farm, err := lemin.BuildFarm([]string{"4", "##start", "s 0 0", "b 1 1", "a 1 0", "##end", "e 2 0", "s-b", "b-e", "s-a", "a-e"})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+	s, a, b, e := farm.Start(), farm.Room("a"), farm.Room("b"), farm.End()
+	paths := [][]*lemin.Room{{s, b, e}, {s, a, e}}
+
+	turns := EstimateTurns(4, paths)
+	if turns != 3 {
+		t.Errorf("Expected 3 turns, got %d", turns)
+	}
+
+	got := strings.Join(pathNames(paths), " ")
+	if got != "s-a-e s-b-e" {
+		t.Errorf("Expected paths sorted by room names, got %s", got)
+	}
+}
+
+// TestEstimateTurnsWithRules tests that a start-end tunnel carrying every ant takes one turn
+func TestEstimateTurnsWithRules(t *testing.T) {
+	farm, err := lemin.BuildFarm(lemin.ParseInput("4\n##start\ns 0 0\na 1 0\n##end\ne 2 0\ns-a\na-e\ns-e"))
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+	direct := [][]*lemin.Room{{farm.Start(), farm.End()}}
+
+	if turns := EstimateTurnsWithRules(4, direct, lemin.ClassicRules); turns != 4 {
+		t.Errorf("Expected 4 turns under classic rules, got %d", turns)
+	}
+	if turns := EstimateTurnsWithRules(4, direct, lemin.RelaxedRules); turns != 1 {
+		t.Errorf("Expected 1 turn under relaxed rules, got %d", turns)
+	}
+}
+
+// TestFindTopPathCombinations tests the ranking by turns, then total moves
+func TestFindTopPathCombinations(t *testing.T) {
+	farm, err := lemin.BuildFarm(lemin.ParseInput("4\n##start\ns 0 0\na 1 0\nb 1 1\nc 1 2\nd 2 2\n##end\ne 2 0\ns-a\na-e\ns-b\nb-e\ns-c\nc-d\nd-e"))
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+	paths := FindAllPaths(farm)
+
+	top := FindTopPathCombinations(farm.AntCount(), paths, 3, lemin.ClassicRules)
+	if len(top) != 3 {
+		t.Fatalf("Expected 3 combinations, got %d", len(top))
+	}
+
+	// Two short paths and all three paths both take 3 turns; the two paths need fewer moves
+	want := []struct{ turns, moves, paths int }{{3, 8, 2}, {3, 9, 3}, {4, 10, 2}}
+	for i, w := range want {
+		if top[i].Turns != w.turns || top[i].Moves != w.moves || len(top[i].Paths) != w.paths {
+			t.Errorf("Alternative %d: expected %d turns, %d moves, %d paths, got %d, %d, %d",
+				i+1, w.turns, w.moves, w.paths, top[i].Turns, top[i].Moves, len(top[i].Paths))
+		}
+	}
+
+	if all := FindTopPathCombinations(farm.AntCount(), paths, 100, lemin.ClassicRules); len(all) != 7 {
+		t.Errorf("Expected every one of the 7 combinations, got %d", len(all))
+	}
+	for _, k := range []int{0, -1} {
+		if none := FindTopPathCombinations(farm.AntCount(), paths, k, lemin.ClassicRules); len(none) != 0 {
+			t.Errorf("Expected no combinations for k = %d, got %d", k, len(none))
+		}
+	}
+
+	var out bytes.Buffer
+	if err := WriteAlternatives(&out, farm.AntCount(), lemin.ClassicRules, top); err != nil {
+		t.Fatalf("WriteAlternatives returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "s-c-d-e (1)") {
+		t.Errorf("Expected the third path of the second plan, got:\n%s", out.String())
+	}
+}
+
+// TestAlternativePlans tests that the plan the run uses comes first, whatever the rules
+func TestAlternativePlans(t *testing.T) {
+	farm, err := lemin.BuildFarm(lemin.ParseInput("4\n##start\ns 0 0\na 1 0\n##end\ne 2 0\ns-a\na-e\ns-e"))
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	for _, rules := range []lemin.Rules{lemin.ClassicRules, lemin.StrictRules, lemin.RelaxedRules} {
+		best, err := solveFarm(farm, rules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plans := alternativePlans(farm, best, 3, rules)
+		if len(plans) != 3 {
+			t.Fatalf("%s rules: expected 3 plans, got %d", rules.Name, len(plans))
+		}
+		if !samePaths(plans[0].Paths, best.Paths) || plans[0].Turns != best.Turns {
+			t.Errorf("%s rules: expected plan #1 to be %v in %d turns, got %v in %d",
+				rules.Name, pathNames(best.Paths), best.Turns, pathNames(plans[0].Paths), plans[0].Turns)
+		}
+		for i, plan := range plans[1:] {
+			if samePaths(plan.Paths, best.Paths) {
+				t.Errorf("%s rules: plan #%d repeats plan #1", rules.Name, i+2)
+			}
+		}
+
+		// Every plan takes the turns shown when run under the same rules
+		for i, plan := range plans {
+			if turns := RunSimulationWithRules(farm, plan.Paths, rules); len(turns) != plan.Turns {
+				t.Errorf("%s rules: plan #%d %v shows %d turns but runs in %d",
+					rules.Name, i+1, pathNames(plan.Paths), plan.Turns, len(turns))
+			}
+		}
+	}
+
+	if plans := alternativePlans(farm, PathCombination{}, 0, lemin.ClassicRules); plans != nil {
+		t.Errorf("Expected no plans for k = 0, got %d", len(plans))
+	}
+}
+
+// TestUnbuiltFarm tests that zero farms and rooms, not built by BuildFarm, are refused
+func TestUnbuiltFarm(t *testing.T) {
+	farm := &lemin.Farm{}
+	if _, err := FindAllPathsContext(context.Background(), farm, 0); !errors.Is(err, ErrUnbuiltFarm) {
+		t.Errorf("Expected ErrUnbuiltFarm from FindAllPathsContext, got %v", err)
+	}
+
+	// Every zero room has ID 0, which would make all paths overlap
+	s, a, b, e := &lemin.Room{}, &lemin.Room{}, &lemin.Room{}, &lemin.Room{}
+	paths := [][]*lemin.Room{{s, a, e}, {s, b, e}}
+	if _, err := FindNonOverlappingPathSetsContext(context.Background(), paths); !errors.Is(err, ErrUnbuiltFarm) {
+		t.Errorf("Expected ErrUnbuiltFarm from FindNonOverlappingPathSetsContext, got %v", err)
+	}
+
+	defer func() {
+		if r := recover(); r != ErrUnbuiltFarm {
+			t.Errorf("Expected NewSimulation to panic with ErrUnbuiltFarm, got %v", r)
+		}
+	}()
+	NewSimulation(farm, paths[:1], lemin.ClassicRules)
+}
+
+// layeredFarm builds a farm with depth layers of width rooms between start
+// and end. Each room links to the room beside it and the next one over in the
+// following layer; tunnels go both ways, so paths may also step back a layer.
+func layeredFarm(tb testing.TB, ants, width, depth int) *lemin.Farm {
+	room := func(layer, i int) string { return fmt.Sprintf("r%d_%d", layer, i) }
+
+	lines := []string{fmt.Sprint(ants), "##start", "s 0 0", "##end", fmt.Sprintf("e %d 0", depth+1)}
+	for layer := range depth {
+		for i := range width {
+			lines = append(lines, fmt.Sprintf("%s %d %d", room(layer, i), layer+1, i))
+		}
+	}
+	for i := range width {
+		lines = append(lines, "s-"+room(0, i), room(depth-1, i)+"-e")
+		for layer := range depth - 1 {
+			lines = append(lines, room(layer, i)+"-"+room(layer+1, i), room(layer, i)+"-"+room(layer+1, (i+1)%width))
+		}
+	}
+
+	farm, err := lemin.BuildFarm(lines)
+	if err != nil {
+		tb.Fatalf("BuildFarm returned error: %v", err)
+	}
+	return farm
+}
+
+// BenchmarkFindAllPaths measures the path search on a farm with 22592 paths
+func BenchmarkFindAllPaths(b *testing.B) {
+	farm := layeredFarm(b, 1, 4, 5)
+	for b.Loop() {
+		FindAllPaths(farm)
+	}
+}
+
+// BenchmarkFindNonOverlappingPathSets measures the search for combinations of 2448 paths
+func BenchmarkFindNonOverlappingPathSets(b *testing.B) {
+	paths := FindAllPaths(layeredFarm(b, 1, 4, 4))
+	for b.Loop() {
+		FindNonOverlappingPathSets(paths)
+	}
+}
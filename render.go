@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// RenderOptions controls the images drawn of a simulation
+type RenderOptions struct {
+	Width, Height int           // Image size in pixels
+	Delay         time.Duration // How long each turn is shown in a GIF
+	PathColors    bool          // Draw each chosen path and its ants in their own color
+}
+
+// DefaultRenderOptions returns 800x600 frames shown for half a second each
+func DefaultRenderOptions() RenderOptions {
+	return RenderOptions{Width: 800, Height: 600, Delay: 500 * time.Millisecond, PathColors: true}
+}
+
+// Limits of the render options
+const (
+	maxImageSize = 4096                          // Largest width or height, in pixels
+	maxGIFDelay  = 65535 * 10 * time.Millisecond // Longest delay a GIF frame can hold
+)
+
+// Validate checks that the images can be drawn: a size from 1 to maxImageSize
+// on each side and a delay from 0 to maxGIFDelay
+func (o RenderOptions) Validate() error {
+	if o.Width <= 0 || o.Height <= 0 || o.Width > maxImageSize || o.Height > maxImageSize {
+		return fmt.Errorf("ERROR: invalid image size %dx%d (each side from 1 to %d)", o.Width, o.Height, maxImageSize)
+	}
+	if o.Delay < 0 || o.Delay > maxGIFDelay {
+		return fmt.Errorf("ERROR: invalid delay %v (from 0 to %v)", o.Delay, maxGIFDelay)
+	}
+	return nil
+}
+
+// Palette indexes used by the renderer
+const (
+	colorBackground = iota
+	colorTunnel
+	colorRoom
+	colorOutline
+	colorStart
+	colorEnd
+	colorAnt
+	colorFirstPath // Path colors follow
+)
+
+// renderPalette holds every color the renderer uses, so frames can be paletted GIF images
+var renderPalette = color.Palette{
+	color.RGBA{0x1e, 0x1e, 0x24, 0xff}, // Background
+	color.RGBA{0x5a, 0x5a, 0x64, 0xff}, // Tunnel
+	color.RGBA{0x2b, 0x2b, 0x33, 0xff}, // Room
+	color.RGBA{0xcc, 0xcc, 0xcc, 0xff}, // Room outline
+	color.RGBA{0x66, 0xbb, 0x6a, 0xff}, // Start
+	color.RGBA{0xef, 0x53, 0x50, 0xff}, // End
+	color.RGBA{0xff, 0xff, 0xff, 0xff}, // Ant without path color
+	color.RGBA{0x4f, 0xc3, 0xf7, 0xff}, // Paths
+	color.RGBA{0xff, 0xb7, 0x4d, 0xff},
+	color.RGBA{0x81, 0xc7, 0x84, 0xff},
+	color.RGBA{0xba, 0x68, 0xc8, 0xff},
+	color.RGBA{0xf0, 0x62, 0x92, 0xff},
+	color.RGBA{0xff, 0xf1, 0x76, 0xff},
+	color.RGBA{0x4d, 0xb6, 0xac, 0xff},
+	color.RGBA{0xa1, 0x88, 0x7f, 0xff},
+}
+
+// renderer draws frames of one simulation
+type renderer struct {
+	farm      *lemin.Farm
+	opts      RenderOptions
+	tunnelOf  map[[2]*lemin.Room]int             // Path index of every tunnel on a chosen path, both ways
+	antPath   map[int]int                        // Path index of every ant, known once it leaves the start
+	positions []map[int]*lemin.Room              // Ant positions before the first turn and after each turn
+	toImage   func(room *lemin.Room) image.Point // Maps room coordinates to pixels
+}
+
+// newRenderer prepares the colors and positions needed to draw every turn
+func newRenderer(farm *lemin.Farm, paths [][]*lemin.Room, turns [][]string, opts RenderOptions) *renderer {
+	r := &renderer{
+		farm:      farm,
+		opts:      opts,
+		tunnelOf:  make(map[[2]*lemin.Room]int),
+		antPath:   make(map[int]int),
+		positions: ReplayPositions(farm, turns),
+	}
+
+	for i, path := range paths {
+		for j := 1; j < len(path); j++ {
+			r.tunnelOf[[2]*lemin.Room{path[j-1], path[j]}] = i
+			r.tunnelOf[[2]*lemin.Room{path[j], path[j-1]}] = i
+		}
+	}
+
+	// An ant's path is the one its first step goes to
+	for t := 1; t < len(r.positions); t++ {
+		for id, room := range r.positions[t] {
+			if _, known := r.antPath[id]; !known && room != farm.Start() {
+				r.antPath[id] = r.firstStepPath(paths, room)
+			}
+		}
+	}
+
+	r.toImage = fitToImage(farm, opts.Width, opts.Height)
+	return r
+}
+
+// firstStepPath finds the path whose second room is room
+func (r *renderer) firstStepPath(paths [][]*lemin.Room, room *lemin.Room) int {
+	for i, path := range paths {
+		if len(path) > 1 && path[1] == room {
+			return i
+		}
+	}
+	return -1
+}
+
+// fitToImage maps farm coordinates into the image, keeping the aspect ratio
+func fitToImage(farm *lemin.Farm, width, height int) func(room *lemin.Room) image.Point {
+	const margin = 30
+
+	minX, minY := int64(math.MaxInt64), int64(math.MaxInt64)
+	maxX, maxY := int64(math.MinInt64), int64(math.MinInt64)
+	for _, room := range farm.Rooms() {
+		minX, maxX = min(minX, room.X()), max(maxX, room.X())
+		minY, maxY = min(minY, room.Y()), max(maxY, room.Y())
+	}
+
+	// Coordinates span the whole int64 range, so the differences are taken in float64
+	spanX, spanY := float64(maxX)-float64(minX), float64(maxY)-float64(minY)
+	scale := math.Min(float64(width-2*margin)/math.Max(spanX, 1), float64(height-2*margin)/math.Max(spanY, 1))
+
+	return func(room *lemin.Room) image.Point {
+		return image.Point{
+			X: margin + int((float64(room.X())-float64(minX))*scale),
+			Y: margin + int((float64(room.Y())-float64(minY))*scale),
+		}
+	}
+}
+
+// pathColor returns the palette index for a path, or fallback without path colors
+func (r *renderer) pathColor(path int, fallback uint8) uint8 {
+	if !r.opts.PathColors || path < 0 {
+		return fallback
+	}
+	return uint8(colorFirstPath + path%(len(renderPalette)-colorFirstPath))
+}
+
+// frame draws the farm with the ants where they are after the given turn
+func (r *renderer) frame(turn int) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, r.opts.Width, r.opts.Height), renderPalette)
+	draw.Draw(img, img.Bounds(), image.NewUniform(renderPalette[colorBackground]), image.Point{}, draw.Src)
+
+	// Tunnels, thicker and colored when a chosen path uses them
+	for _, tunnel := range r.farm.Tunnels() {
+		from, to := r.toImage(tunnel.From()), r.toImage(tunnel.To())
+		if path, ok := r.tunnelOf[[2]*lemin.Room{tunnel.From(), tunnel.To()}]; ok && r.opts.PathColors {
+			drawLine(img, from, to, 3, r.pathColor(path, colorTunnel))
+		} else {
+			drawLine(img, from, to, 1, colorTunnel)
+		}
+	}
+
+	// Rooms, with start and end outlined in their own colors
+	for _, room := range r.farm.Rooms() {
+		center := r.toImage(room)
+		outline := uint8(colorOutline)
+		if room == r.farm.Start() {
+			outline = colorStart
+		} else if room == r.farm.End() {
+			outline = colorEnd
+		}
+		fillCircle(img, center, 10, outline)
+		fillCircle(img, center, 8, colorRoom)
+	}
+
+	// Ants in the rooms between start and end
+	for id := 1; id <= r.farm.AntCount(); id++ {
+		room := r.positions[turn][id]
+		if room == r.farm.Start() || room == r.farm.End() {
+			continue
+		}
+		path, ok := r.antPath[id]
+		if !ok {
+			path = -1
+		}
+		fillCircle(img, r.toImage(room), 6, r.pathColor(path, colorAnt))
+	}
+
+	return img
+}
+
+// RenderGIF writes an animated GIF with one frame for the start and one per turn
+func RenderGIF(w io.Writer, farm *lemin.Farm, paths [][]*lemin.Room, turns [][]string, opts RenderOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	r := newRenderer(farm, paths, turns, opts)
+
+	anim := &gif.GIF{}
+	delay := int(opts.Delay / (10 * time.Millisecond)) // GIF delays are in 100ths of a second
+	for turn := range r.positions {
+		anim.Image = append(anim.Image, r.frame(turn))
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	return gif.EncodeAll(w, anim)
+}
+
+// RenderFrames writes one PNG per turn into dir, named frame_000.png, frame_001.png, ...
+func RenderFrames(dir string, farm *lemin.Farm, paths [][]*lemin.Room, turns [][]string, opts RenderOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	r := newRenderer(farm, paths, turns, opts)
+	for turn := range r.positions {
+		file, err := os.Create(filepath.Join(dir, fmt.Sprintf("frame_%03d.png", turn)))
+		if err != nil {
+			return err
+		}
+		err = png.Encode(file, r.frame(turn))
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReplayPositions replays the moves and returns where every ant is before the
+// first turn (index 0) and after each turn. Illegal moves are applied as given.
+func ReplayPositions(farm *lemin.Farm, turns [][]string) []map[int]*lemin.Room {
+	current := make(map[int]*lemin.Room)
+	for id := 1; id <= farm.AntCount(); id++ {
+		current[id] = farm.Start()
+	}
+
+	positions := []map[int]*lemin.Room{copyPositions(current)}
+	for _, moves := range turns {
+		for _, move := range moves {
+			antID, roomName, err := lemin.ParseAntMove(move)
+			if room := farm.Room(roomName); err == nil && room != nil {
+				current[antID] = room
+			}
+		}
+		positions = append(positions, copyPositions(current))
+	}
+
+	return positions
+}
+
+// copyPositions returns a copy of the ant positions
+func copyPositions(positions map[int]*lemin.Room) map[int]*lemin.Room {
+	result := make(map[int]*lemin.Room, len(positions))
+	for id, room := range positions {
+		result[id] = room
+	}
+	return result
+}
+
+// drawLine draws a line of the given thickness between two points
+func drawLine(img *image.Paletted, from, to image.Point, thickness int, c uint8) {
+	steps := max(abs(to.X-from.X), abs(to.Y-from.Y), 1)
+	for i := 0; i <= steps; i++ {
+		p := image.Point{
+			X: from.X + (to.X-from.X)*i/steps,
+			Y: from.Y + (to.Y-from.Y)*i/steps,
+		}
+		fillCircle(img, p, thickness/2, c)
+	}
+}
+
+// fillCircle fills a disc of the given radius around center
+func fillCircle(img *image.Paletted, center image.Point, radius int, c uint8) {
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx*dx+dy*dy > radius*radius {
+				continue
+			}
+			p := image.Point{X: center.X + dx, Y: center.Y + dy}
+			if p.In(img.Rect) {
+				img.SetColorIndex(p.X, p.Y, c)
+			}
+		}
+	}
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
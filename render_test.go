@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"testing"
+	"time"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// TestRenderGIF tests that the GIF has one frame per turn plus the start
+func TestRenderGIF(t *testing.T) {
+	farm, err := lemin.BuildFarm([]string{
+		"2",
+		"##start",
+		"s 0 0",
+		"a 5 0",
+		"##end",
+		"e 10 0",
+		"s-a",
+		"a-e",
+	})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	best, err := solveFarm(farm, lemin.ClassicRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	turns := RunSimulation(farm, best.Paths)
+
+	opts := RenderOptions{Width: 200, Height: 100, Delay: 250 * time.Millisecond, PathColors: true}
+	var buf bytes.Buffer
+	if err := RenderGIF(&buf, farm, best.Paths, turns, opts); err != nil {
+		t.Fatalf("RenderGIF returned error: %v", err)
+	}
+
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("RenderGIF wrote an invalid GIF: %v", err)
+	}
+	if len(anim.Image) != len(turns)+1 {
+		t.Errorf("Expected %d frames, got %d", len(turns)+1, len(anim.Image))
+	}
+	if anim.Config.Width != 200 || anim.Config.Height != 100 {
+		t.Errorf("Expected 200x100 image, got %dx%d", anim.Config.Width, anim.Config.Height)
+	}
+	if anim.Delay[0] != 25 {
+		t.Errorf("Expected a delay of 25, got %d", anim.Delay[0])
+	}
+
+	// After the first turn ant 1 is in room a, drawn in the color of the first path
+	toImage := fitToImage(farm, opts.Width, opts.Height)
+	center := toImage(farm.Room("a"))
+	if got := anim.Image[1].ColorIndexAt(center.X, center.Y); got != colorFirstPath {
+		t.Errorf("Expected ant color %d in room a, got %d", colorFirstPath, got)
+	}
+	if got := anim.Image[0].ColorIndexAt(center.X, center.Y); got != colorRoom {
+		t.Errorf("Expected empty room a before the first turn, got color %d", got)
+	}
+}
+
+// TestFitToImage_ExtremeCoordinates tests that rooms at the ends of the int64 range stay in the image
+func TestFitToImage_ExtremeCoordinates(t *testing.T) {
+	farm, err := lemin.BuildFarmWithOptions([]string{
+		"1",
+		"##start",
+		"s -9223372036854775808 -9223372036854775808",
+		"a 0 0",
+		"##end",
+		"e 9223372036854775807 9223372036854775807",
+		"s-a",
+		"a-e",
+	}, lemin.ParseOptions{})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	toImage := fitToImage(farm, 200, 200)
+	want := map[string]image.Point{"s": {30, 30}, "a": {100, 100}, "e": {170, 170}}
+	for name, point := range want {
+		got := toImage(farm.Room(name))
+		if abs(got.X-point.X) > 1 || abs(got.Y-point.Y) > 1 {
+			t.Errorf("Expected room %s near %v, got %v", name, point, got)
+		}
+	}
+}
+
+// TestRenderOptions_Validate tests the allowed image sizes and delays
+func TestRenderOptions_Validate(t *testing.T) {
+	tests := []struct {
+		opts  RenderOptions
+		valid bool
+	}{
+		{DefaultRenderOptions(), true},
+		{RenderOptions{Width: 4096, Height: 1}, true},
+		{RenderOptions{Width: 0, Height: 600}, false},
+		{RenderOptions{Width: 800, Height: 100000}, false},
+		{RenderOptions{Width: 800, Height: 600, Delay: -time.Second}, false},
+		{RenderOptions{Width: 800, Height: 600, Delay: time.Hour}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.opts.Validate(); (err == nil) != test.valid {
+			t.Errorf("Validate(%+v) returned %v", test.opts, err)
+		}
+	}
+}
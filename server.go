@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// indexHTML is the web visualizer page. It draws the farm from /farm.json
+// on a canvas and needs nothing else, so it works offline.
+//
+//go:embed web/index.html
+var indexHTML []byte
+
+// runServe handles the serve subcommand:
+// go run . serve [--addr host:port] [--strict] [--rules name] [--tie-break list] [--avoid rooms] [--max-body bytes] [--timeout duration] [--max-rooms n] [--max-ants n] [--max-paths n] [--max-solves n] [filename]
+func runServe(args []string) {
+	defaults := defaultConfig()
+
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	strict := flags.Bool("strict", false, "reject input that doesn't follow the canonical lem-in order")
+	rules := flags.String("rules", defaults.rules.Name, "movement rules: classic, strict or relaxed")
+	tieBreak := flags.String("tie-break", "", "comma-separated tie-breakers between plans with the same turns: moves, paths, load, avoid")
+	avoid := flags.String("avoid", "", "comma-separated rooms to steer ants away from, besides ##avoid rooms (implies --tie-break avoid)")
+	maxBody := flags.Int64("max-body", defaults.maxBody, "largest accepted request body in bytes")
+	timeout := flags.Duration("timeout", defaults.timeout, "time limit for solving one request")
+	maxRooms := flags.Int("max-rooms", defaults.limits.rooms, "most rooms in a farm the server solves, 0 for no limit")
+	maxAnts := flags.Int("max-ants", defaults.limits.ants, "most ants in a farm the server solves, 0 for no limit")
+	maxPaths := flags.Int("max-paths", defaults.limits.paths, "most paths from start to end the server searches, 0 for no limit")
+	maxSolves := flags.Int("max-solves", defaults.limits.solves, "most requests solved at once, 0 for no limit")
+	flags.Parse(args)
+
+	if flags.NArg() > 1 {
+		fmt.Println("ERROR: usage --> go run . serve [--addr host:port] [--strict] [--rules name] [--tie-break list] [--avoid rooms] [--max-body bytes] [--timeout duration] [--max-rooms n] [--max-ants n] [--max-paths n] [--max-solves n] [filename]")
+		return
+	}
+
+	cfg := defaults
+	cfg.parse.Strict = *strict
+	cfg.warnings = os.Stderr
+	ruleSet, err := lemin.RulesByName(*rules)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	cfg.rules = ruleSet
+	cfg.ties, err = ParseTieBreakers(*tieBreak, *avoid)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	cfg.maxBody = *maxBody
+	cfg.timeout = *timeout
+	cfg.limits = solveLimits{rooms: *maxRooms, ants: *maxAnts, paths: *maxPaths, solves: *maxSolves}
+
+	filename := flags.Arg(0)
+	if filename != "" {
+		fmt.Printf("Serving %s on http://%s\n", filename, *addr)
+	} else {
+		fmt.Printf("Serving the API on http://%s\n", *addr)
+	}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           newServer(filename, cfg),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Println("ERROR:", err)
+	}
+}
+
+// newServer returns the handler for the web visualizer of the farm in filename
+// and for the solving API. The file is read again on every request, so edits
+// show up on reload. Without a filename only the API is useful.
+func newServer(filename string, cfg config) http.Handler {
+	mux := http.NewServeMux()
+	slots := newSolveSlots(cfg.limits)
+
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(indexHTML)
+	})
+
+	mux.HandleFunc("GET /farm.json", func(w http.ResponseWriter, r *http.Request) {
+		if filename == "" {
+			writeError(w, http.StatusNotFound, "not_found", errors.New("ERROR: no farm file is being served"))
+			return
+		}
+
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal", fmt.Errorf("ERROR: could not read file: %w", err))
+			return
+		}
+
+		solution, err := withTimeout(r.Context(), cfg, slots, func(ctx context.Context) (*lemin.Solution, error) {
+			return solveLines(ctx, lemin.ParseInput(string(content)), cfg)
+		})
+		if err != nil {
+			writeSolveError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, solution)
+	})
+
+	mux.HandleFunc("GET /health", handleHealth)
+	mux.HandleFunc("POST /solve", handleSolve(cfg, slots))
+	mux.HandleFunc("POST /verify", handleVerify(cfg, slots))
+
+	return mux
+}
+
+// solveLines parses and solves a farm given as lem-in lines, within the
+// configured limits. Parser warnings go to cfg.warnings. It stops with the
+// context's error once ctx is done.
+func solveLines(ctx context.Context, lines []string, cfg config) (*lemin.Solution, error) {
+	farm, err := lemin.BuildFarmWithOptions(lines, cfg.parse)
+	if err != nil {
+		return nil, err
+	}
+	writeWarnings(cfg.warnings, farm)
+	if err := cfg.limits.check(farm); err != nil {
+		return nil, err
+	}
+
+	best, err := solveFarmContext(ctx, farm, cfg.rules, cfg.limits.paths, cfg.ties...)
+	if err != nil {
+		return nil, err
+	}
+
+	turns, err := NewSimulation(farm, best.Paths, cfg.rules).RunContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return lemin.NewSolution(farm, best.Paths, turns), nil
+}
+
+// apiError is the body of every error response
+type apiError struct {
+	Error  string `json:"error"`            // The message, as the command line prints it
+	Code   string `json:"code"`             // Kind of error, for programs
+	Detail string `json:"detail,omitempty"` // What is wrong with the farm, without the "ERROR:" prefix
+}
+
+// writeJSON sends value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError sends err as a JSON error response with the given code
+func writeError(w http.ResponseWriter, status int, code string, err error) {
+	writeJSON(w, status, apiError{Error: err.Error(), Code: code})
+}
+
+// writeFarmError sends a parser or solver error, keeping its message and
+// splitting out the part after "ERROR: invalid data format, "
+func writeFarmError(w http.ResponseWriter, err error) {
+	body := apiError{Error: err.Error(), Code: "invalid_data_format"}
+	body.Detail = strings.TrimPrefix(err.Error(), "ERROR: invalid data format, ")
+	writeJSON(w, http.StatusUnprocessableEntity, body)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// TestServer_Visualizer tests the page and the farm endpoint of the web visualizer
+func TestServer_Visualizer(t *testing.T) {
+	server := httptest.NewServer(newServer("testdata/example.txt", defaultConfig()))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("GET / returned %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	resp, err = http.Get(server.URL + "/farm.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var solution lemin.Solution
+	if err := json.NewDecoder(resp.Body).Decode(&solution); err != nil {
+		t.Fatalf("GET /farm.json returned invalid JSON: %v", err)
+	}
+	if solution.Ants != 3 || solution.Start != "1" || solution.End != "0" {
+		t.Errorf("Unexpected farm: %d ants from %s to %s", solution.Ants, solution.Start, solution.End)
+	}
+	if len(solution.Rooms) != 8 || len(solution.Links) != 12 || solution.Turns != 4 || len(solution.Moves) != 4 {
+		t.Errorf("Expected 8 rooms, 12 links and 4 turns, got %d, %d and %d",
+			len(solution.Rooms), len(solution.Links), solution.Turns)
+	}
+}
+
+// TestServer_InvalidFarm tests that parse errors are returned as JSON
+func TestServer_InvalidFarm(t *testing.T) {
+	server := httptest.NewServer(newServer("testdata/no_path.txt", defaultConfig()))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/farm.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var body struct{ Error string }
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("error response is not JSON: %v", err)
+	}
+	if resp.StatusCode != http.StatusUnprocessableEntity || !strings.Contains(body.Error, "no path") {
+		t.Errorf("Expected 422 with no path error, got %d %q", resp.StatusCode, body.Error)
+	}
+}
+
+// TestSolveLines_Warnings tests that what lenient parsing let through is printed to cfg.warnings
+func TestSolveLines_Warnings(t *testing.T) {
+	var warnings bytes.Buffer
+	cfg := defaultConfig()
+	cfg.warnings = &warnings
+
+	lines := []string{"1", "##start", "s 0 0", "m -1 5", "##end", "e 2 2", "s-m", "m-e"}
+	if _, err := solveLines(context.Background(), lines, cfg); err != nil {
+		t.Fatalf("solveLines returned error: %v", err)
+	}
+	if !strings.HasPrefix(warnings.String(), "WARNING:") || !strings.Contains(warnings.String(), "out of range") {
+		t.Errorf("Expected an out of range warning, got %q", warnings.String())
+	}
+}
@@ -1,30 +1,142 @@
 package main
 
 import (
-	"fmt"
-	"strings"
+	"context"
+
+	"github.com/nido007/Lem-in-visual/lemin"
 )
 
 // Ant represents a single ant in the simulation
 type Ant struct {
-	ID   int     // Unique number for this ant
-	Path []*Room // The route this ant will follow
-	Pos  int     // Current position along the path (0 = start)
+	ID   int           // Unique number for this ant
+	Path []*lemin.Room // The route this ant will follow
+	Pos  int           // Current position along the path (0 = start)
 }
 
-// RunSimulation moves all ants from start to end, one turn at a time
-func RunSimulation(farm *Farm, paths [][]*Room) {
-	totalAnts := farm.AntCount
-	numPaths := len(paths)
+// SimulationObserver is told what happens during a simulation, so logging,
+// metrics or checks can follow a run without changing the loop. Turns are
+// numbered from 1. The ants passed in belong to the simulation and must not
+// be changed.
+type SimulationObserver interface {
+	OnTurnStart(turn int)
+	OnAntLaunched(turn int, ant *Ant)                                 // The ant got its number and may leave the start
+	OnAntMoved(turn int, ant *Ant, from, to *lemin.Room)              // The ant went through the tunnel from-to
+	OnAntBlocked(turn int, ant *Ant, next *lemin.Room, reason string) // The ant couldn't enter next this turn
+	OnAntArrived(turn int, ant *Ant)                                  // The ant reached the end
+	OnTurnEnd(turn int, moves []string)                               // The moves made this turn
+}
+
+// NopObserver ignores every event. Embed it to implement only some of the methods.
+type NopObserver struct{}
+
+func (NopObserver) OnTurnStart(int)                                {}
+func (NopObserver) OnAntLaunched(int, *Ant)                        {}
+func (NopObserver) OnAntMoved(int, *Ant, *lemin.Room, *lemin.Room) {}
+func (NopObserver) OnAntBlocked(int, *Ant, *lemin.Room, string)    {}
+func (NopObserver) OnAntArrived(int, *Ant)                         {}
+func (NopObserver) OnTurnEnd(int, []string)                        {}
+
+// observers passes every event on to each of its observers in order
+type observers []SimulationObserver
+
+func (o observers) OnTurnStart(turn int) {
+	for _, obs := range o {
+		obs.OnTurnStart(turn)
+	}
+}
+
+func (o observers) OnAntLaunched(turn int, ant *Ant) {
+	for _, obs := range o {
+		obs.OnAntLaunched(turn, ant)
+	}
+}
 
-	if numPaths == 0 {
-		return // No paths available
+func (o observers) OnAntMoved(turn int, ant *Ant, from, to *lemin.Room) {
+	for _, obs := range o {
+		obs.OnAntMoved(turn, ant, from, to)
 	}
+}
 
-	// Create queues of ants for each path
-	queues := make([][]*Ant, numPaths)
+func (o observers) OnAntBlocked(turn int, ant *Ant, next *lemin.Room, reason string) {
+	for _, obs := range o {
+		obs.OnAntBlocked(turn, ant, next, reason)
+	}
+}
+
+func (o observers) OnAntArrived(turn int, ant *Ant) {
+	for _, obs := range o {
+		obs.OnAntArrived(turn, ant)
+	}
+}
+
+func (o observers) OnTurnEnd(turn int, moves []string) {
+	for _, obs := range o {
+		obs.OnTurnEnd(turn, moves)
+	}
+}
+
+// RunSimulation moves all ants from start to end, one turn at a time.
+// Ants are numbered in launch order: each turn the next ant of every path
+// is launched, following the order of paths, so the same farm always
+// produces the same moves. It returns the moves made in each turn.
+func RunSimulation(farm *lemin.Farm, paths [][]*lemin.Room) [][]string {
+	return RunSimulationWithObservers(farm, paths)
+}
+
+// RunSimulationWithObservers runs the simulation like RunSimulation and
+// tells every observer what happens, in the order they are given
+func RunSimulationWithObservers(farm *lemin.Farm, paths [][]*lemin.Room, obs ...SimulationObserver) [][]string {
+	return RunSimulationWithRules(farm, paths, lemin.ClassicRules, obs...)
+}
+
+// RunSimulationWithRules runs the simulation with observers, following the
+// given movement rules. When a start-end tunnel carries any number of ants,
+// every ant of a direct path is launched in the first turn.
+func RunSimulationWithRules(farm *lemin.Farm, paths [][]*lemin.Room, rules lemin.Rules, obs ...SimulationObserver) [][]string {
+	return NewSimulation(farm, paths, rules, obs...).Run()
+}
+
+// Simulation is one run of ants through a farm. It keeps every changing
+// detail of the run itself and only reads the farm and the paths, so any
+// number of simulations may run on the same farm at once.
+type Simulation struct {
+	farm   *lemin.Farm
+	paths  [][]*lemin.Room
+	rules  lemin.Rules
+	notify observers
+
+	queues      [][]*Ant     // Ants waiting in the start room, by path
+	activeAnts  []*Ant       // Ants launched so far, in launch order
+	nextID      int          // Next ant number to assign
+	finished    int          // How many ants have reached the end
+	turn        int          // Turns played so far
+	occupied    lemin.Bitset // Rooms holding an ant, by ID
+	usedTunnels lemin.Bitset // Tunnels used this turn, by TunnelSlot
+}
+
+// NewSimulation prepares a run of the farm's ants over paths. Ants are
+// numbered in launch order: each turn the next ant of every path is
+// launched, following the order of paths. The farm must come from
+// lemin.BuildFarm and the paths from its rooms; NewSimulation panics with
+// ErrUnbuiltFarm when the farm has no graph.
+func NewSimulation(farm *lemin.Farm, paths [][]*lemin.Room, rules lemin.Rules, obs ...SimulationObserver) *Simulation {
+	if farm.Graph() == nil {
+		panic(ErrUnbuiltFarm)
+	}
+	s := &Simulation{
+		farm:        farm,
+		paths:       paths,
+		rules:       rules,
+		notify:      observers(obs),
+		queues:      make([][]*Ant, len(paths)),
+		nextID:      1,
+		occupied:    lemin.NewBitset(farm.Graph().NumRooms()),
+		usedTunnels: lemin.NewBitset(farm.Graph().NumEdges()),
+	}
 
 	// Distribute ants among paths
+	totalAnts := farm.AntCount()
+	numPaths := len(paths)
 	for i, path := range paths {
 		// Calculate how many ants go on this path
 		antsForThisPath := totalAnts / numPaths
@@ -33,9 +145,9 @@ func RunSimulation(farm *Farm, paths [][]*Room) {
 		}
 
 		// Create ants for this path
-		queues[i] = make([]*Ant, antsForThisPath)
+		s.queues[i] = make([]*Ant, antsForThisPath)
 		for j := 0; j < antsForThisPath; j++ {
-			queues[i][j] = &Ant{
+			s.queues[i][j] = &Ant{
 				ID:   0,    // Will be assigned when launched
 				Path: path, // Route to follow
 				Pos:  0,    // Start at beginning
@@ -43,92 +155,126 @@ func RunSimulation(farm *Farm, paths [][]*Room) {
 		}
 	}
 
-	nextID := 1                   // Next ant number to assign
-	activeAnts := make([]*Ant, 0) // Ants currently moving
-	finished := 0                 // How many ants have reached the end
+	return s
+}
 
-	// Clear all room occupancy
-	for _, room := range farm.Rooms {
-		room.Occupied = false
-	}
+// Done reports whether every ant has reached the end, or no path was given
+func (s *Simulation) Done() bool {
+	return len(s.paths) == 0 || s.finished >= s.farm.AntCount()
+}
 
-	// Main simulation loop - continue until all ants reach the end
-	for finished < totalAnts {
-		var moves []string // Moves made this turn
+// Run plays every remaining turn and returns the moves made in each one
+func (s *Simulation) Run() [][]string {
+	turns, _ := s.RunContext(context.Background())
+	return turns
+}
 
-		// Phase 1: Launch new ants (one per path if possible)
-		for i := range queues {
-			if len(queues[i]) > 0 {
-				// Take the next ant from this path's queue
-				ant := queues[i][0]
-				queues[i] = queues[i][1:] // Remove from queue
+// RunContext is Run that stops with the context's error once ctx is done
+func (s *Simulation) RunContext(ctx context.Context) ([][]string, error) {
+	var turns [][]string
+	for !s.Done() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 
-				ant.ID = nextID
-				nextID++
-				activeAnts = append(activeAnts, ant)
-			}
+		// Record all moves for this turn
+		if moves := s.Step(); len(moves) > 0 {
+			turns = append(turns, moves)
 		}
+	}
+	return turns, nil
+}
 
-		// Phase 2: Move existing ants
-		usedTunnels := make(map[string]bool) // Track which tunnels are used this turn
+// Step plays the next turn and returns its moves
+func (s *Simulation) Step() []string {
+	farm, g, rules := s.farm, s.farm.Graph(), s.rules
 
-		for _, ant := range activeAnts {
-			// Skip ants that have already reached the end
-			if ant.Pos >= len(ant.Path)-1 {
-				continue
-			}
+	s.turn++
+	turn := s.turn
+	var moves []string // Moves made this turn
+	s.notify.OnTurnStart(turn)
 
-			// Determine current and next rooms
-			var currentRoom *Room
-			if ant.Pos == 0 {
-				currentRoom = farm.Start
-			} else {
-				currentRoom = ant.Path[ant.Pos]
-			}
-			nextRoom := ant.Path[ant.Pos+1]
+	// Phase 1: Launch new ants (one per path if possible)
+	for i := range s.queues {
+		launch := min(1, len(s.queues[i]))
+		if rules.DirectUnlimited && len(s.paths[i]) == 2 {
+			launch = len(s.queues[i])
+		}
+		for range launch {
+			// Take the next ant from this path's queue
+			ant := s.queues[i][0]
+			s.queues[i] = s.queues[i][1:] // Remove from queue
+
+			ant.ID = s.nextID
+			s.nextID++
+			s.activeAnts = append(s.activeAnts, ant)
+			s.notify.OnAntLaunched(turn, ant)
+		}
+	}
 
-			// Create tunnel identifier
-			tunnelID := currentRoom.Name + "->" + nextRoom.Name
+	// Phase 2: Move existing ants
+	s.usedTunnels.Clear()
 
-			// Check if tunnel is already used this turn
-			if usedTunnels[tunnelID] {
-				continue // Can't use same tunnel twice in one turn
-			}
+	for _, ant := range s.activeAnts {
+		// Skip ants that have already reached the end
+		if ant.Pos >= len(ant.Path)-1 {
+			continue
+		}
 
-			// Check if next room is occupied (except start/end)
-			if nextRoom != farm.Start && nextRoom != farm.End && nextRoom.Occupied {
-				continue // Room is occupied
-			}
+		// Determine current and next rooms
+		var currentRoom *lemin.Room
+		if ant.Pos == 0 {
+			currentRoom = farm.Start()
+		} else {
+			currentRoom = ant.Path[ant.Pos]
+		}
+		nextRoom := ant.Path[ant.Pos+1]
 
-			// Free the previous room (if not start/end)
-			if ant.Pos > 0 {
-				prevRoom := ant.Path[ant.Pos]
-				if prevRoom != farm.Start && prevRoom != farm.End {
-					prevRoom.Occupied = false
-				}
-			}
+		// Find the tunnel's slot, -1 when the tunnel has no limit
+		tunnel := rules.TunnelSlot(g, int32(currentRoom.ID()), int32(nextRoom.ID()))
 
-			// Move the ant
-			ant.Pos++
-			usedTunnels[tunnelID] = true
+		// Check if tunnel is already used this turn
+		if tunnel >= 0 && s.usedTunnels.Has(tunnel) {
+			s.notify.OnAntBlocked(turn, ant, nextRoom, "tunnel already used this turn")
+			continue // Can't use same tunnel twice in one turn
+		}
 
-			// Occupy the new room (if not start/end)
-			if nextRoom != farm.Start && nextRoom != farm.End {
-				nextRoom.Occupied = true
+		// Check if next room is occupied (except start/end)
+		if nextRoom != farm.Start() && nextRoom != farm.End() && s.occupied.Has(nextRoom.ID()) {
+			s.notify.OnAntBlocked(turn, ant, nextRoom, "room occupied")
+			continue // Room is occupied
+		}
+
+		// Free the previous room (if not start/end)
+		if ant.Pos > 0 {
+			prevRoom := ant.Path[ant.Pos]
+			if prevRoom != farm.Start() && prevRoom != farm.End() {
+				s.occupied.Unset(prevRoom.ID())
 			}
+		}
 
-			// Record the move
-			moves = append(moves, PrintAntMove(ant.ID, nextRoom.Name))
+		// Move the ant
+		ant.Pos++
+		if tunnel >= 0 {
+			s.usedTunnels.Set(tunnel)
+		}
 
-			// Check if ant reached the end
-			if nextRoom == farm.End {
-				finished++
-			}
+		// Occupy the new room (if not start/end)
+		if nextRoom != farm.Start() && nextRoom != farm.End() {
+			s.occupied.Set(nextRoom.ID())
 		}
 
-		// Print all moves for this turn
-		if len(moves) > 0 {
-			fmt.Println(strings.Join(moves, " "))
+		// Record the move
+		moves = append(moves, PrintAntMove(ant.ID, nextRoom.Name()))
+		s.notify.OnAntMoved(turn, ant, currentRoom, nextRoom)
+
+		// Check if ant reached the end
+		if nextRoom == farm.End() {
+			s.finished++
+			s.notify.OnAntArrived(turn, ant)
 		}
 	}
+	s.notify.OnTurnEnd(turn, moves)
+
+	return moves
 }
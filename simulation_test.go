@@ -0,0 +1,171 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// recordingObserver counts the events of a simulation
+type recordingObserver struct {
+	NopObserver
+	launched, arrived map[int]int // Turn of each ant's launch and arrival
+	moves             []string    // Every move, rebuilt from OnAntMoved
+	turnEnds          [][]string
+}
+
+func (r *recordingObserver) OnAntLaunched(turn int, ant *Ant) { r.launched[ant.ID] = turn }
+func (r *recordingObserver) OnAntArrived(turn int, ant *Ant)  { r.arrived[ant.ID] = turn }
+func (r *recordingObserver) OnTurnEnd(turn int, moves []string) {
+	r.turnEnds = append(r.turnEnds, moves)
+}
+func (r *recordingObserver) OnAntMoved(turn int, ant *Ant, from, to *lemin.Room) {
+	r.moves = append(r.moves, PrintAntMove(ant.ID, to.Name()))
+}
+
+// TestRunSimulationWithObservers tests that observers see every launch, move and arrival
+func TestRunSimulationWithObservers(t *testing.T) {
+	farm, err := lemin.BuildFarm(lemin.ParseInput("4\n##start\ns 0 0\na 1 0\nb 1 1\n##end\ne 2 0\ns-a\na-e\ns-b\nb-e"))
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+	best, err := solveFarm(farm, lemin.ClassicRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := &recordingObserver{launched: map[int]int{}, arrived: map[int]int{}}
+	second := &recordingObserver{launched: map[int]int{}, arrived: map[int]int{}}
+	turns := RunSimulationWithObservers(farm, best.Paths, first, second)
+
+	var want []string
+	for _, moves := range turns {
+		want = append(want, moves...)
+	}
+	for _, obs := range []*recordingObserver{first, second} {
+		if len(obs.launched) != 4 || len(obs.arrived) != 4 {
+			t.Errorf("Expected 4 launches and arrivals, got %v and %v", obs.launched, obs.arrived)
+		}
+		if strings.Join(obs.moves, " ") != strings.Join(want, " ") {
+			t.Errorf("Expected moves %v, got %v", want, obs.moves)
+		}
+		if len(obs.turnEnds) != len(turns) {
+			t.Errorf("Expected %d turn ends, got %d", len(turns), len(obs.turnEnds))
+		}
+	}
+	if first.arrived[4] != len(turns) {
+		t.Errorf("Expected the last ant to arrive on turn %d, got %d", len(turns), first.arrived[4])
+	}
+
+	if plain := RunSimulation(farm, best.Paths); len(plain) != len(turns) {
+		t.Errorf("Expected the same turns without observers, got %d and %d", len(plain), len(turns))
+	}
+}
+
+// TestRunSimulationWithRules tests that relaxed rules send every ant through a start-end tunnel at once
+func TestRunSimulationWithRules(t *testing.T) {
+	farm, err := lemin.BuildFarm(lemin.ParseInput("5\n##start\ns 0 0\na 1 0\n##end\ne 2 0\ns-a\na-e\ns-e"))
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	for _, tt := range []struct {
+		rules lemin.Rules
+		turns int
+	}{{lemin.ClassicRules, 3}, {lemin.StrictRules, 3}, {lemin.RelaxedRules, 1}} {
+		best, err := solveFarm(farm, tt.rules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		turns := RunSimulationWithRules(farm, best.Paths, tt.rules)
+		if len(turns) != tt.turns || best.Turns != tt.turns {
+			t.Errorf("%s rules: expected %d turns, got %d (estimated %d)", tt.rules.Name, tt.turns, len(turns), best.Turns)
+		}
+		for _, v := range lemin.VerifyMovesWithRules(farm, turns, tt.rules) {
+			t.Errorf("%s rules: illegal move: %s", tt.rules.Name, v)
+		}
+	}
+}
+
+// TestSimulation_Step tests that stepping through a simulation gives the same turns as Run
+func TestSimulation_Step(t *testing.T) {
+	farm, err := lemin.BuildFarm(lemin.ParseInput("4\n##start\ns 0 0\na 1 0\nb 1 1\n##end\ne 2 0\ns-a\na-e\ns-b\nb-e"))
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+	best, err := solveFarm(farm, lemin.ClassicRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := RunSimulation(farm, best.Paths)
+	sim := NewSimulation(farm, best.Paths, lemin.ClassicRules)
+	var got [][]string
+	for !sim.Done() {
+		got = append(got, sim.Step())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected turns %v, got %v", want, got)
+	}
+}
+
+// TestRunSimulation_Parallel runs many simulations of one farm at once; run
+// it with -race to check that they don't share any state
+func TestRunSimulation_Parallel(t *testing.T) {
+	content, err := os.ReadFile("testdata/complex.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	farm, err := lemin.BuildFarm(lemin.ParseInput(string(content)))
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	rules := []lemin.Rules{lemin.ClassicRules, lemin.StrictRules, lemin.RelaxedRules}
+	paths := make([][][]*lemin.Room, len(rules))
+	want := make([][][]string, len(rules))
+	for i, r := range rules {
+		best, err := solveFarm(farm, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths[i] = best.Paths
+		want[i] = RunSimulationWithRules(farm, best.Paths, r)
+	}
+
+	var wg sync.WaitGroup
+	for n := range 32 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			i := n % len(rules)
+			if _, err := solveFarm(farm, rules[i]); err != nil {
+				t.Error(err)
+			}
+			turns := RunSimulationWithRules(farm, paths[i], rules[i])
+			if !reflect.DeepEqual(turns, want[i]) {
+				t.Errorf("%s rules: simulation %d gave different turns", rules[i].Name, n)
+			}
+			if violations := lemin.VerifyMovesWithRules(farm, turns, rules[i]); len(violations) > 0 {
+				t.Errorf("%s rules: simulation %d made illegal moves: %v", rules[i].Name, n, violations)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// BenchmarkRunSimulation measures a run of 1000 ants over four paths
+func BenchmarkRunSimulation(b *testing.B) {
+	farm := layeredFarm(b, 1000, 4, 4)
+	best, err := solveFarm(farm, lemin.ClassicRules)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for b.Loop() {
+		RunSimulation(farm, best.Paths)
+	}
+}
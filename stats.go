@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// AntStats describes the trip of one ant
+type AntStats struct {
+	ID       int // Ant number
+	Path     int // Index of the path it took, -1 if it never moved
+	Launched int // Turn of its first move, 0 if it never moved
+	Arrived  int // Turn it reached the end, 0 if it never did
+	Waiting  int // Turns spent in the start room before its first move
+	Blocked  int // Turns after its first move in which it couldn't move
+}
+
+// PathStats describes how much one path was used
+type PathStats struct {
+	Rooms        []string // Room names from start to end
+	Length       int      // Number of moves from start to end
+	Ants         int      // Ants that took this path
+	FirstArrival int      // Turn the first of them reached the end, 0 if none did
+	LastArrival  int      // Turn the last of them reached the end, 0 if none did
+	Utilization  float64  // Ants divided by the most ants the path could deliver in the run
+}
+
+// Stats explains a run: where each ant went and how busy each path was
+type Stats struct {
+	Turns int
+	Ants  []AntStats
+	Paths []PathStats
+}
+
+// ComputeStats replays the moves of each turn on the chosen paths. An ant
+// belongs to the path whose first room it moves to.
+func ComputeStats(farm *lemin.Farm, paths [][]*lemin.Room, turns [][]string) *Stats {
+	stats := &Stats{
+		Turns: len(turns),
+		Ants:  make([]AntStats, farm.AntCount()),
+		Paths: make([]PathStats, len(paths)),
+	}
+
+	for i, path := range paths {
+		rooms := make([]string, len(path))
+		for j, room := range path {
+			rooms[j] = room.Name()
+		}
+		stats.Paths[i] = PathStats{Rooms: rooms, Length: len(path) - 1}
+	}
+
+	moveCount := make([]int, farm.AntCount()) // Moves made by each ant
+	for i := range stats.Ants {
+		stats.Ants[i] = AntStats{ID: i + 1, Path: -1}
+	}
+
+	for t, moves := range turns {
+		turn := t + 1
+		for _, move := range moves {
+			antID, roomName, err := lemin.ParseAntMove(move)
+			if err != nil || antID > farm.AntCount() {
+				continue
+			}
+			ant := &stats.Ants[antID-1]
+			moveCount[antID-1]++
+
+			if ant.Launched == 0 {
+				ant.Launched = turn
+				ant.Path = firstRoomPath(paths, roomName)
+			}
+			if roomName == farm.End().Name() {
+				ant.Arrived = turn
+			}
+		}
+	}
+
+	for i := range stats.Ants {
+		ant := &stats.Ants[i]
+		if ant.Launched == 0 {
+			ant.Waiting = stats.Turns
+			continue
+		}
+		ant.Waiting = ant.Launched - 1
+
+		last := ant.Arrived
+		if last == 0 {
+			last = stats.Turns
+		}
+		ant.Blocked = last - ant.Launched + 1 - moveCount[i]
+
+		if ant.Path < 0 {
+			continue
+		}
+		path := &stats.Paths[ant.Path]
+		path.Ants++
+		if ant.Arrived != 0 {
+			if path.FirstArrival == 0 || ant.Arrived < path.FirstArrival {
+				path.FirstArrival = ant.Arrived
+			}
+			path.LastArrival = max(path.LastArrival, ant.Arrived)
+		}
+	}
+
+	// A path of length L delivers at most one ant per turn from turn L on
+	for i := range stats.Paths {
+		path := &stats.Paths[i]
+		if capacity := stats.Turns - path.Length + 1; capacity > 0 {
+			path.Utilization = float64(path.Ants) / float64(capacity)
+		}
+	}
+
+	return stats
+}
+
+// firstRoomPath finds the path whose first room after start is roomName
+func firstRoomPath(paths [][]*lemin.Room, roomName string) int {
+	for i, path := range paths {
+		if len(path) > 1 && path[1].Name() == roomName {
+			return i
+		}
+	}
+	return -1
+}
+
+// WriteReport writes the statistics as two tables, ants then paths
+func (s *Stats) WriteReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintf(tw, "Statistics: %d ants, %d paths, %d turns\n\n", len(s.Ants), len(s.Paths), s.Turns)
+
+	fmt.Fprintln(tw, "Ant\tPath\tLaunched\tArrived\tWaiting\tBlocked\t")
+	for _, ant := range s.Ants {
+		path := "-"
+		if ant.Path >= 0 {
+			path = fmt.Sprint(ant.Path + 1)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%d\t\n", ant.ID, path, ant.Launched, ant.Arrived, ant.Waiting, ant.Blocked)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "Path\tLength\tAnts\tFirst\tLast\tUtilization\t")
+	for i, path := range s.Paths {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t%.0f%%\t\n", i+1, path.Length, path.Ants, path.FirstArrival, path.LastArrival, path.Utilization*100)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	for i, path := range s.Paths {
+		if _, err := fmt.Fprintf(w, "Path %d: %s\n", i+1, strings.Join(path.Rooms, "-")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// TestComputeStats tests the ant and path figures of the example farm
+func TestComputeStats(t *testing.T) {
+	farm, err := lemin.BuildFarm([]string{
+		"3", "##start", "1 23 3", "2 16 7", "3 16 3", "4 16 5", "5 9 3", "6 1 5", "7 4 8", "##end", "0 9 5",
+		"0-4", "0-6", "1-3", "4-3", "5-2", "3-5", "4-2", "2-1", "7-6", "7-2", "7-4", "6-5",
+	})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	best, err := solveFarm(farm, lemin.ClassicRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := ComputeStats(farm, best.Paths, RunSimulation(farm, best.Paths))
+
+	wantAnts := []AntStats{
+		{ID: 1, Path: 0, Launched: 1, Arrived: 3},
+		{ID: 2, Path: 1, Launched: 1, Arrived: 4},
+		{ID: 3, Path: 0, Launched: 2, Arrived: 4, Waiting: 1},
+	}
+	for i, want := range wantAnts {
+		if stats.Ants[i] != want {
+			t.Errorf("Expected ant stats %+v, got %+v", want, stats.Ants[i])
+		}
+	}
+
+	if len(stats.Paths) != 2 {
+		t.Fatalf("Expected 2 paths, got %d", len(stats.Paths))
+	}
+	first := stats.Paths[0]
+	if first.Length != 3 || first.Ants != 2 || first.FirstArrival != 3 || first.LastArrival != 4 || first.Utilization != 1 {
+		t.Errorf("Unexpected stats for path 1: %+v", first)
+	}
+
+	var report bytes.Buffer
+	if err := stats.WriteReport(&report); err != nil {
+		t.Fatalf("WriteReport returned error: %v", err)
+	}
+	if !strings.Contains(report.String(), "Path 2: 1-3-5-6-0") {
+		t.Errorf("Expected the routes in the report, got:\n%s", report.String())
+	}
+}
+
+// TestComputeStats_Blocked tests that an ant stopped on its way is counted as blocked
+func TestComputeStats_Blocked(t *testing.T) {
+	farm, err := lemin.BuildFarm([]string{"2", "##start", "s 0 0", "a 1 0", "b 2 0", "##end", "e 3 0", "s-a", "a-b", "b-e"})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+	paths := [][]*lemin.Room{{farm.Start(), farm.Room("a"), farm.Room("b"), farm.End()}}
+
+	// Ant 1 waits one turn in room a
+	turns := [][]string{{"L1-a"}, {}, {"L1-b", "L2-a"}, {"L1-e", "L2-b"}, {"L2-e"}}
+	stats := ComputeStats(farm, paths, turns)
+
+	if ant := stats.Ants[0]; ant.Blocked != 1 || ant.Arrived != 4 {
+		t.Errorf("Expected ant 1 blocked once and arriving on turn 4, got %+v", ant)
+	}
+	if ant := stats.Ants[1]; ant.Waiting != 2 || ant.Blocked != 0 {
+		t.Errorf("Expected ant 2 to wait 2 turns, got %+v", ant)
+	}
+	if got := stats.Paths[0].Utilization; got != 2.0/3 {
+		t.Errorf("Expected utilization 2/3, got %v", got)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// TieBreaker chooses between path combinations that take the same number of turns
+type TieBreaker struct {
+	Name string
+
+	// Score rates paths, already in EstimateTurns order, for antCount ants.
+	// Lower is better.
+	Score func(antCount int, paths [][]*lemin.Room) int
+}
+
+// tieBreakerNames lists the tie-breakers in the order they are shown to users
+var tieBreakerNames = []string{"moves", "paths", "load", "avoid"}
+
+// TieBreakerByName returns the named tie-breaker:
+//   - moves: fewest ant moves in total
+//   - paths: fewest paths
+//   - load: fewest ants through the busiest room between start and end
+//   - avoid: fewest ant visits to the rooms avoided reports true for
+func TieBreakerByName(name string, avoided func(room *lemin.Room) bool) (TieBreaker, error) {
+	switch name {
+	case "moves":
+		return TieBreaker{Name: name, Score: TotalMoves}, nil
+	case "paths":
+		return TieBreaker{Name: name, Score: func(antCount int, paths [][]*lemin.Room) int {
+			return len(paths)
+		}}, nil
+	case "load":
+		return TieBreaker{Name: name, Score: MaxRoomLoad}, nil
+	case "avoid":
+		return TieBreaker{Name: name, Score: func(antCount int, paths [][]*lemin.Room) int {
+			return avoidedVisits(antCount, paths, avoided)
+		}}, nil
+	}
+	return TieBreaker{}, fmt.Errorf("ERROR: unknown tie-breaker %q (expected one of %s)", name, strings.Join(tieBreakerNames, ", "))
+}
+
+// MaxRoomLoad returns the most ants that go through a single room between
+// start and end. Paths in a combination share no such room, so it is the
+// most ants on one path that has any.
+func MaxRoomLoad(antCount int, paths [][]*lemin.Room) int {
+	load := 0
+	for i, ants := range DistributeAnts(antCount, paths) {
+		if len(paths[i]) > 2 {
+			load = max(load, ants)
+		}
+	}
+	return load
+}
+
+// avoidedVisits counts how many times ants enter a room avoided reports true for
+func avoidedVisits(antCount int, paths [][]*lemin.Room, avoided func(room *lemin.Room) bool) int {
+	visits := 0
+	for i, ants := range DistributeAnts(antCount, paths) {
+		for _, room := range paths[i][1:] {
+			if avoided(room) {
+				visits += ants
+			}
+		}
+	}
+	return visits
+}
+
+// avoidedRooms reports the rooms named on the command line and those marked
+// with a "##avoid" command in the farm
+func avoidedRooms(names []string) func(room *lemin.Room) bool {
+	return func(room *lemin.Room) bool {
+		_, tagged := room.Command("avoid")
+		return tagged || slices.Contains(names, room.Name())
+	}
+}
+
+// ParseTieBreakers reads a comma-separated list of tie-breaker names, as
+// given to --tie-break. avoid is the comma-separated list of rooms given to
+// --avoid; naming rooms adds the avoid tie-breaker last if it isn't listed.
+func ParseTieBreakers(list, avoid string) ([]TieBreaker, error) {
+	names := splitList(list)
+	rooms := splitList(avoid)
+	if len(rooms) > 0 && !slices.Contains(names, "avoid") {
+		names = append(names, "avoid")
+	}
+
+	var ties []TieBreaker
+	for _, name := range names {
+		tie, err := TieBreakerByName(name, avoidedRooms(rooms))
+		if err != nil {
+			return nil, err
+		}
+		ties = append(ties, tie)
+	}
+	return ties, nil
+}
+
+// splitList splits a comma-separated flag value, dropping spaces and empty items
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
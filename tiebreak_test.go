@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// TestFindOptimalPathCombination_TieBreakers tests that tie-breakers choose
+// between plans with the same number of turns
+func TestFindOptimalPathCombination_TieBreakers(t *testing.T) {
+	// With 2 ants, s-a-e alone and s-a-e with s-b-c-e both take 3 turns
+	input := []string{"2", "##start", "s 0 0", "##avoid", "a 1 0", "b 1 1", "c 2 1", "##end", "e 3 0",
+		"s-a", "a-e", "s-b", "b-c", "c-e"}
+
+	tests := []struct {
+		name     string
+		tieBreak string
+		avoid    string
+		want     string
+	}{
+		{"first by default", "", "", "s-a-e"},
+		{"fewest moves", "moves", "", "s-a-e"},
+		{"fewest paths", "paths", "", "s-a-e"},
+		{"lowest room load", "load", "", "s-a-e s-b-c-e"},
+		{"avoid tagged rooms", "avoid", "", "s-a-e s-b-c-e"},
+		{"avoid named rooms", "avoid", "c", "s-a-e"},
+		{"first tie-breaker decides", "paths,load", "", "s-a-e"},
+		{"next tie-breaker on a tie", "avoid,load", "c", "s-a-e s-b-c-e"},
+		{"avoid implied by rooms", "", " c , a", "s-a-e"},
+		{"avoid implied after others", "paths", "b", "s-a-e"},
+		{"avoid names trimmed", "load,avoid", "a, b", "s-a-e s-b-c-e"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			farm, err := lemin.BuildFarm(input)
+			if err != nil {
+				t.Fatalf("BuildFarm returned error: %v", err)
+			}
+			ties, err := ParseTieBreakers(tt.tieBreak, tt.avoid)
+			if err != nil {
+				t.Fatalf("ParseTieBreakers returned error: %v", err)
+			}
+
+			best, err := solveFarm(farm, lemin.ClassicRules, ties...)
+			if err != nil {
+				t.Fatalf("solveFarm returned error: %v", err)
+			}
+			if best.Turns != 3 {
+				t.Errorf("Expected 3 turns, got %d", best.Turns)
+			}
+			if got := strings.Join(pathNames(best.Paths), " "); got != tt.want {
+				t.Errorf("Expected paths %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestParseTieBreakers_Unknown tests that an unknown name is rejected
+func TestParseTieBreakers_Unknown(t *testing.T) {
+	_, err := ParseTieBreakers("moves,shortest", "")
+	if err == nil || !strings.Contains(err.Error(), `unknown tie-breaker "shortest"`) {
+		t.Errorf("Expected unknown tie-breaker error, got %v", err)
+	}
+}
@@ -1,3 +1,7 @@
 module visualizer
 
 go 1.24.4
+
+require github.com/nido007/Lem-in-visual v0.0.0
+
+replace github.com/nido007/Lem-in-visual => ../
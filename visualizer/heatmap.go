@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// Size of the heatmap panel in characters
+const (
+	heatWidth  = 64
+	heatHeight = 16
+)
+
+// heatColors are ANSI 256-color codes from quiet (blue) to busy (red)
+var heatColors = []int{21, 27, 33, 39, 45, 51, 49, 46, 118, 190, 226, 220, 214, 208, 202, 196}
+
+// heatCell is one character of the heatmap panel
+type heatCell struct {
+	char  rune
+	color int // ANSI 256-color code, 0 for the default color
+	bold  bool
+}
+
+// writeHeatmap draws every room at its position as "name:visits", colored
+// from blue for rooms no ant entered to red for the busiest when color is
+// set. Start and end are left uncolored since every ant passes through them.
+// Bottleneck rooms are marked with '!' and printed in bold.
+func writeHeatmap(w io.Writer, farm *lemin.Farm, heat *lemin.Heatmap, color bool) {
+	grid := make([][]heatCell, heatHeight)
+	for y := range grid {
+		grid[y] = make([]heatCell, heatWidth)
+		for x := range grid[y] {
+			grid[y][x] = heatCell{char: ' '}
+		}
+	}
+
+	minX, maxX, minY, maxY := heatBounds(heat)
+	busiest := 0
+	for _, room := range heat.Rooms {
+		if room.Room != farm.Start() && room.Room != farm.End() {
+			busiest = max(busiest, room.Visits)
+		}
+	}
+
+	for _, room := range heat.Rooms {
+		label := []rune(fmt.Sprintf("%s:%d", room.Room.Name(), room.Visits))
+		if room.Bottleneck {
+			label = append(label, '!')
+		}
+
+		// Leave room for the label at the right edge
+		col := scale(room.Room.X(), minX, maxX, heatWidth-len(label)+1)
+		row := scale(room.Room.Y(), minY, maxY, heatHeight)
+
+		color := 0
+		switch {
+		case room.Room == farm.Start() || room.Room == farm.End():
+		case busiest == 0:
+			color = heatColors[0]
+		default:
+			color = heatColors[room.Visits*(len(heatColors)-1)/busiest]
+		}
+		for i, char := range label {
+			if col+i < heatWidth {
+				grid[row][col+i] = heatCell{char: char, color: color, bold: room.Bottleneck}
+			}
+		}
+	}
+
+	legend := "room:visits, ! = bottleneck"
+	if color {
+		legend = "room:visits, blue = quiet, red = busy, ! = bottleneck"
+	}
+	fmt.Fprintf(w, "🔥 HEATMAP over %d turns (%s)\n", heat.Turns, legend)
+	fmt.Fprintln(w, "+"+strings.Repeat("-", heatWidth)+"+")
+	for _, row := range grid {
+		var sb strings.Builder
+		var style heatCell // Color and weight of the previous character
+		for _, cell := range row {
+			// Switch styles only where they change
+			if color && (cell.color != style.color || cell.bold != style.bold) {
+				sb.WriteString("\033[0m")
+				if cell.bold {
+					sb.WriteString("\033[1m")
+				}
+				if cell.color != 0 {
+					fmt.Fprintf(&sb, "\033[38;5;%dm", cell.color)
+				}
+				style = cell
+			}
+			sb.WriteRune(cell.char)
+		}
+		if style.color != 0 || style.bold {
+			sb.WriteString("\033[0m")
+		}
+		fmt.Fprintln(w, "|"+sb.String()+"|")
+	}
+	fmt.Fprintln(w, "+"+strings.Repeat("-", heatWidth)+"+")
+
+	for _, tunnel := range heat.Tunnels {
+		if tunnel.Traversals > 0 {
+			fmt.Fprintf(w, "  %s-%s: %d ants\n", tunnel.Tunnel.From().Name(), tunnel.Tunnel.To().Name(), tunnel.Traversals)
+		}
+	}
+}
+
+// heatBounds returns the smallest and largest room coordinates
+func heatBounds(heat *lemin.Heatmap) (minX, maxX, minY, maxY int64) {
+	for i, room := range heat.Rooms {
+		if i == 0 {
+			minX, maxX, minY, maxY = room.Room.X(), room.Room.X(), room.Room.Y(), room.Room.Y()
+			continue
+		}
+		minX, maxX = min(minX, room.Room.X()), max(maxX, room.Room.X())
+		minY, maxY = min(minY, room.Room.Y()), max(maxY, room.Room.Y())
+	}
+	return minX, maxX, minY, maxY
+}
+
+// scale maps v from [lo, hi] to a cell index in [0, size). The differences
+// are taken in float64, since they overflow int64 for coordinates far apart.
+func scale(v, lo, hi int64, size int) int {
+	if hi == lo || size <= 1 {
+		return 0
+	}
+	ratio := (float64(v) - float64(lo)) / (float64(hi) - float64(lo))
+	cell := int(ratio * float64(size-1))
+	return max(0, min(cell, size-1))
+}
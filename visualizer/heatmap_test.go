@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// TestWriteHeatmap tests that rooms are placed by their coordinates
+func TestWriteHeatmap(t *testing.T) {
+	farm, err := lemin.BuildFarm([]string{"1", "##start", "s 0 0", "a 5 10", "##end", "e 10 0", "s-a", "a-e"})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	var out bytes.Buffer
+	writeHeatmap(&out, farm, lemin.ComputeHeatmap(farm, [][]string{{"L1-a"}, {"L1-e"}}), true)
+	lines := strings.Split(out.String(), "\n")
+
+	// Start and end share the top row, room a is on the bottom one
+	if !strings.HasPrefix(lines[2], "|s:0") || !strings.HasSuffix(lines[2], "e:1|") {
+		t.Errorf("Expected start and end on the first row, got %q", lines[2])
+	}
+	if !strings.Contains(lines[heatHeight+1], "a:1!") {
+		t.Errorf("Expected the bottleneck a on the last row, got %q", lines[heatHeight+1])
+	}
+}
+
+// TestWriteHeatmap_ExtremeCoordinates tests that rooms at the ends of the int64 range keep their places
+func TestWriteHeatmap_ExtremeCoordinates(t *testing.T) {
+	farm, err := lemin.BuildFarmWithOptions([]string{
+		"1",
+		"##start",
+		"s -9223372036854775808 -9223372036854775808",
+		"a 0 0",
+		"##end",
+		"e 9223372036854775807 9223372036854775807",
+		"s-a",
+		"a-e",
+	}, lemin.ParseOptions{})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	var out bytes.Buffer
+	writeHeatmap(&out, farm, lemin.ComputeHeatmap(farm, [][]string{{"L1-a"}, {"L1-e"}}), false)
+	lines := strings.Split(out.String(), "\n")
+
+	// Start in the top left corner, a in the middle, end in the bottom right corner
+	if !strings.HasPrefix(lines[2], "|s:0") {
+		t.Errorf("Expected start on the first row, got %q", lines[2])
+	}
+	middle := lines[2+(heatHeight-1)/2]
+	if col := strings.Index(middle, "a:1"); col < heatWidth/2-4 || col > heatWidth/2+2 {
+		t.Errorf("Expected a in the middle row and column, got %q", middle)
+	}
+	if !strings.HasSuffix(lines[heatHeight+1], "e:1|") {
+		t.Errorf("Expected end on the last row, got %q", lines[heatHeight+1])
+	}
+}
@@ -2,118 +2,136 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"maps"
 	"os"
-	"os/exec"
-	"runtime"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
-)
 
-type Room struct {
-	Name string
-	X, Y int
-}
+	"github.com/nido007/Lem-in-visual/lemin"
+)
 
 type Ant struct {
 	ID       int
 	RoomName string
 }
 
-type Farm struct {
-	Rooms    map[string]*Room
-	Links    [][]string
-	AntCount int
-	Start    string
-	End      string
+// screen shows the frames of a run: animated when the output is a terminal,
+// otherwise printed one after another so CI logs and recordings stay readable
+type screen struct {
+	interactive bool          // Wait for Enter, clear between frames and pause between turns
+	color       bool          // Color invalid moves and the heatmap with ANSI codes
+	finalOnly   bool          // Show only the final state
+	delay       time.Duration // Pause between turns when interactive
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or a file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
 
-func clearScreen() {
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		cmd = exec.Command("cmd", "/c", "cls")
+// clear starts a new frame: an ANSI clear in a terminal, a blank line otherwise
+func (s screen) clear() {
+	if s.interactive {
+		fmt.Print("\033[H\033[2J")
 	} else {
-		cmd = exec.Command("clear")
+		fmt.Println()
 	}
-	cmd.Stdout = os.Stdout
-	cmd.Run()
 }
 
-func parseInput() (*Farm, []string) {
-	scanner := bufio.NewScanner(os.Stdin)
-	var lines []string
-	var movesStarted bool
-	var moves []string
+// waitForEnter shows prompt and waits for Enter when interactive
+func (s screen) waitForEnter(prompt string) {
+	if s.interactive {
+		fmt.Println(prompt)
+		fmt.Scanln()
+	}
+}
+
+// pause waits between turns when interactive
+func (s screen) pause() {
+	if s.interactive {
+		time.Sleep(s.delay)
+	}
+}
 
+// maxLineSize is the longest input line accepted. A turn of a farm with
+// thousands of ants, or a JSON trace on one line, is far beyond bufio's 64KB default.
+const maxLineSize = 256 << 20
+
+// readInput reads the lem-in output, or the JSON trace printed by lem-in --json,
+// and splits it into the farm and the moves of each turn. The farm is built with
+// the main program's parser, so problems are reported the same way. An "ERROR:"
+// line printed by lem-in is returned as the error. The farm is nil when there is
+// no input at all.
+func readInput(input io.Reader) (*lemin.Farm, [][]string, error) {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	var lines []string
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			movesStarted = true
-			continue
-		}
+		lines = append(lines, strings.TrimSpace(scanner.Text()))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("ERROR: could not read input: %w", err)
+	}
 
-		if movesStarted {
-			moves = append(moves, line)
-		} else {
-			lines = append(lines, line)
+	// lem-in prints only the error when the farm is invalid
+	first := ""
+	for _, line := range lines {
+		if line != "" {
+			first = line
+			break
 		}
 	}
-
-	farm := &Farm{
-		Rooms: make(map[string]*Room),
-		Links: [][]string{},
+	switch {
+	case first == "":
+		return nil, nil, nil
+	case strings.HasPrefix(first, "ERROR:"):
+		return nil, nil, errors.New(first)
+	case strings.HasPrefix(first, "{"):
+		return readTrace(strings.Join(lines, "\n"))
 	}
 
-	var expectStart, expectEnd bool
-
-	for i, line := range lines {
-		if i == 0 {
-			antCount, _ := strconv.Atoi(line)
-			farm.AntCount = antCount
-			continue
-		}
+	farmLines, turns := lemin.SplitTranscript(lines)
+	farm, err := lemin.BuildFarm(farmLines)
+	if err != nil {
+		return nil, nil, err
+	}
+	return farm, turns, nil
+}
 
-		if strings.HasPrefix(line, "#") {
-			if line == "##start" {
-				expectStart = true
-			} else if line == "##end" {
-				expectEnd = true
-			}
-			continue
-		}
+// readTrace builds the farm and moves from a JSON trace
+func readTrace(data string) (*lemin.Farm, [][]string, error) {
+	var solution lemin.Solution
+	if err := json.Unmarshal([]byte(data), &solution); err != nil {
+		return nil, nil, fmt.Errorf("ERROR: invalid JSON trace: %w", err)
+	}
 
-		if strings.Contains(line, " ") {
-			parts := strings.Fields(line)
-			if len(parts) == 3 {
-				name := parts[0]
-				x, _ := strconv.Atoi(parts[1])
-				y, _ := strconv.Atoi(parts[2])
-
-				room := &Room{Name: name, X: x, Y: y}
-				farm.Rooms[name] = room
-
-				if expectStart {
-					farm.Start = name
-					expectStart = false
-				}
-				if expectEnd {
-					farm.End = name
-					expectEnd = false
-				}
-			}
-		} else if strings.Contains(line, "-") {
-			parts := strings.Split(line, "-")
-			if len(parts) == 2 {
-				farm.Links = append(farm.Links, parts)
-			}
-		}
+	farm, err := lemin.BuildFarm(solution.FarmLines())
+	if err != nil {
+		return nil, nil, err
 	}
+	return farm, solution.Moves, nil
+}
 
-	return farm, moves
+// openInput opens the file named on the command line, or stdin without one or for "-"
+func openInput(args []string) (io.ReadCloser, error) {
+	if len(args) == 0 || args[0] == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: could not read file: %w", err)
+	}
+	return file, nil
 }
 
-func createDynamicVisualization(farm *Farm, ants map[int]*Ant) {
+func createDynamicVisualization(farm *lemin.Farm, ants map[int]*Ant) {
 	fmt.Println()
 	fmt.Println("        _________________")
 	fmt.Println("       /                 \\")
@@ -177,66 +195,141 @@ func hasAntInRoom(ants map[int]*Ant, roomName string) bool {
 func main() {
 	fmt.Println("🎨 Lem-in ASCII Art Visualizer")
 
-	farm, moves := parseInput()
-
-	if len(farm.Rooms) == 0 {
-		fmt.Println("❌ No farm data received!")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: ./lem-in <filename> | ./visualizer, or ./visualizer [file]")
+		fmt.Fprintln(flag.CommandLine.Output(), "The file holds lem-in output or the JSON trace of lem-in --json.")
+		flag.PrintDefaults()
+	}
+	noInteractive := flag.Bool("no-interactive", false, "print every frame one after another without waiting (default when stdout isn't a terminal)")
+	finalOnly := flag.Bool("final-only", false, "show only the final state")
+	rules := flag.String("rules", lemin.ClassicRules.Name, "movement rules the moves are checked against: classic, strict or relaxed")
+	heatmap := flag.Bool("heatmap", false, "after the final state, show how busy every room was at its position")
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
 		return
 	}
 
-	fmt.Printf("✅ Farm loaded: %d rooms, %d ants\n", len(farm.Rooms), farm.AntCount)
-	fmt.Println("Press Enter to see the farm layout...")
-	fmt.Scanln()
+	interactive := !*noInteractive && isTerminal(os.Stdout)
+	s := screen{
+		interactive: interactive,
+		color:       interactive,
+		finalOnly:   *finalOnly,
+		delay:       2 * time.Second,
+	}
 
-	clearScreen()
-	fmt.Println("Which corresponds to the following representation:")
+	ruleSet, err := lemin.RulesByName(*rules)
+	if err != nil {
+		fmt.Println(s.red("❌ " + err.Error()))
+		return
+	}
 
-	ants := make(map[int]*Ant)
-	createDynamicVisualization(farm, ants)
+	input, err := openInput(flag.Args())
+	if err != nil {
+		fmt.Println(s.red("❌ " + err.Error()))
+		return
+	}
+	farm, turns, err := readInput(input)
+	input.Close()
+	if err != nil {
+		fmt.Println(s.red("❌ " + err.Error()))
+		return
+	}
+	if farm == nil {
+		fmt.Println("❌ No farm data received!")
+		return
+	}
 
-	fmt.Println("Press Enter to start animation...")
-	fmt.Scanln()
+	fmt.Printf("✅ Farm loaded: %d rooms, %d ants\n", farm.NumRooms(), farm.AntCount())
+	replay := NewReplay(farm, ruleSet)
 
-	for turnNum, move := range moves {
-		clearScreen()
-		fmt.Printf("🐜 TURN %d: %s\n", turnNum+1, move)
-		fmt.Println(strings.Repeat("=", 50))
+	if !s.finalOnly {
+		s.waitForEnter("Press Enter to see the farm layout...")
+		s.clear()
+		fmt.Println("Which corresponds to the following representation:")
+		createDynamicVisualization(farm, replay.Ants())
+		s.waitForEnter("Press Enter to start animation...")
+	}
 
-		moveParts := strings.Fields(move)
-		for _, movePart := range moveParts {
-			if strings.Contains(movePart, "-") {
-				parts := strings.Split(movePart, "-")
-				if len(parts) == 2 && strings.HasPrefix(parts[0], "L") {
-					antIDStr := strings.TrimPrefix(parts[0], "L")
-					antID, err := strconv.Atoi(antIDStr)
-					if err == nil {
-						roomName := parts[1]
-						ants[antID] = &Ant{ID: antID, RoomName: roomName}
-						if roomName == farm.End {
-							delete(ants, antID)
-						}
-					}
-				}
-			}
+	for turnNum, moveParts := range turns {
+		invalid := replay.ApplyTurn(moveParts)
+		if s.finalOnly {
+			continue
 		}
 
+		s.clear()
+		fmt.Printf("🐜 TURN %d: %s\n", turnNum+1, s.highlightMoves(moveParts, invalid))
+		fmt.Println(strings.Repeat("=", 50))
+
 		fmt.Println("Which corresponds to the following representation:")
+		ants := replay.Ants()
 		createDynamicVisualization(farm, ants)
 
 		if len(ants) > 0 {
+			// In ant order, so recorded runs are the same every time
 			fmt.Printf("Active ants: ")
-			for id, ant := range ants {
-				fmt.Printf("A%d@%s ", id, ant.RoomName)
+			for _, id := range slices.Sorted(maps.Keys(ants)) {
+				fmt.Printf("A%d@%s ", id, ants[id].RoomName)
 			}
 			fmt.Println()
 		}
 
-		time.Sleep(2 * time.Second)
+		// Explain what was wrong with the moves shown in red or marked with '!'
+		for i, movePart := range moveParts {
+			if reason, bad := invalid[i]; bad {
+				fmt.Println(s.red("❌ " + movePart + ": " + reason))
+			}
+		}
+
+		s.pause()
 	}
 
-	clearScreen()
+	replay.Finish()
+
+	s.clear()
 	fmt.Println("🎉 FINAL STATE:")
 	fmt.Println("Which corresponds to the following representation:")
-	createDynamicVisualization(farm, ants)
-	fmt.Println("✨ All ants have reached their destination!")
+	createDynamicVisualization(farm, replay.Ants())
+
+	if *heatmap {
+		writeHeatmap(os.Stdout, farm, lemin.ComputeHeatmap(farm, turns), s.color)
+		fmt.Println()
+	}
+
+	if len(replay.Violations) == 0 {
+		fmt.Println("✨ All ants have reached their destination!")
+		return
+	}
+
+	fmt.Println(s.red(fmt.Sprintf("❌ %d problem(s) found while replaying the moves:", len(replay.Violations))))
+	for _, v := range replay.Violations {
+		fmt.Println("  " + v.String())
+	}
+}
+
+// highlightMoves joins the moves of a turn, showing invalid ones in red, or
+// followed by '!' without colors
+func (s screen) highlightMoves(moves []string, invalid map[int]string) string {
+	parts := make([]string, len(moves))
+	for i, move := range moves {
+		_, bad := invalid[i]
+		switch {
+		case bad && s.color:
+			parts[i] = s.red(move)
+		case bad:
+			parts[i] = move + "!"
+		default:
+			parts[i] = move
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// red wraps text in ANSI escape codes so terminals print it in red, and
+// leaves it plain without colors
+func (s screen) red(text string) string {
+	if !s.color {
+		return text
+	}
+	return "\033[31m" + text + "\033[0m"
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// Replay tracks where every ant is while the moves are applied turn by turn.
+// The moves are checked by lemin.MoveChecker, the same as lem-in's verifier.
+type Replay struct {
+	farm       *lemin.Farm
+	checker    *lemin.MoveChecker
+	Violations []lemin.Violation
+}
+
+// NewReplay puts every ant in the start room. Moves are checked against the given rules.
+func NewReplay(farm *lemin.Farm, rules lemin.Rules) *Replay {
+	return &Replay{
+		farm:    farm,
+		checker: lemin.NewMoveChecker(farm, rules),
+	}
+}
+
+// ApplyTurn applies the legal moves of one turn and returns the explanation
+// for every invalid one, keyed by its index in moves. Invalid moves are not applied,
+// except moves into a room that ends the turn with more than one ant.
+func (r *Replay) ApplyTurn(moves []string) map[int]string {
+	invalid := make(map[int]string)
+	entered := make(map[*lemin.Room][]int) // Legal moves that entered each room this turn
+
+	r.checker.StartTurn()
+	for i, move := range moves {
+		if reason := r.checker.CheckMove(move); reason != "" {
+			invalid[i] = reason
+			continue
+		}
+		_, roomName, _ := lemin.ParseAntMove(move)
+		room := r.farm.Room(roomName)
+		entered[room] = append(entered[room], i)
+	}
+
+	// Every room except start and end holds at most one ant after the turn
+	for _, room := range r.checker.Crowded() {
+		for _, i := range entered[room] {
+			invalid[i] = fmt.Sprintf("room %s holds more than one ant", room.Name())
+		}
+	}
+
+	for i, move := range moves {
+		if reason, bad := invalid[i]; bad {
+			r.Violations = append(r.Violations, lemin.Violation{Turn: r.checker.Turn(), Move: move, Reason: reason})
+		}
+	}
+
+	return invalid
+}
+
+// Finish reports the ants that never reached the end
+func (r *Replay) Finish() {
+	r.Violations = append(r.Violations, r.checker.Finish()...)
+}
+
+// Ants returns the ants standing in rooms between start and end
+func (r *Replay) Ants() map[int]*Ant {
+	ants := make(map[int]*Ant)
+	for id := 1; id <= r.farm.AntCount(); id++ {
+		room := r.checker.Position(id)
+		if room != r.farm.Start() && room != r.farm.End() {
+			ants[id] = &Ant{ID: id, RoomName: room.Name()}
+		}
+	}
+	return ants
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nido007/Lem-in-visual/lemin"
+)
+
+// TestReplay_InvalidMoves tests that illegal moves are explained and not applied
+func TestReplay_InvalidMoves(t *testing.T) {
+	farm, err := lemin.BuildFarm([]string{"2", "##start", "s 0 0", "a 1 0", "b 1 1", "##end", "e 2 0",
+		"s-a", "a-e", "s-b", "b-e", "a-b"})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+
+	replay := NewReplay(farm, lemin.ClassicRules)
+
+	invalid := replay.ApplyTurn(strings.Fields("L1-a L2-a L3-b L1-x"))
+	want := map[int]string{1: "already used", 2: "unknown ant", 3: "unknown room"}
+	for i, reason := range want {
+		if !strings.Contains(invalid[i], reason) {
+			t.Errorf("Expected move %d to be invalid with %q, got %q", i, reason, invalid[i])
+		}
+	}
+	if _, bad := invalid[0]; bad {
+		t.Errorf("Expected L1-a to be valid, got %q", invalid[0])
+	}
+
+	// Ant 2 joins ant 1 in room a
+	invalid = replay.ApplyTurn(strings.Fields("L2-b"))
+	invalid = replay.ApplyTurn(strings.Fields("L2-a"))
+	if !strings.Contains(invalid[0], "holds more than one ant") {
+		t.Errorf("Expected a crowded room, got %q", invalid[0])
+	}
+
+	invalid = replay.ApplyTurn(strings.Fields("L1-e L2-s"))
+	if len(invalid) != 0 {
+		t.Errorf("Expected valid moves, got %v", invalid)
+	}
+
+	replay.Finish()
+	last := replay.Violations[len(replay.Violations)-1]
+	if !strings.Contains(last.Reason, "ant 2 did not reach the end") {
+		t.Errorf("Expected ant 2 to be reported, got %q", last.Reason)
+	}
+	if len(replay.Violations) != 5 {
+		t.Errorf("Expected 5 violations, got %d: %v", len(replay.Violations), replay.Violations)
+	}
+}
+
+// TestReplay_MatchesVerifier tests that the replay finds the same illegal moves
+// as lemin.VerifyMovesWithRules under every rule set
+func TestReplay_MatchesVerifier(t *testing.T) {
+	farm, err := lemin.BuildFarm([]string{"3", "##start", "s 0 0", "a 1 0", "##end", "e 2 0", "s-a", "a-e", "s-e"})
+	if err != nil {
+		t.Fatalf("BuildFarm returned error: %v", err)
+	}
+	turns := [][]string{{"L1-a", "L2-e", "L3-e"}, {"L1-s", "L2-a"}, {"L1-e"}}
+
+	for _, rules := range []lemin.Rules{lemin.ClassicRules, lemin.StrictRules, lemin.RelaxedRules} {
+		var want []string
+		for _, v := range lemin.VerifyMovesWithRules(farm, turns, rules) {
+			if v.Move != "" {
+				want = append(want, v.String())
+			}
+		}
+
+		replay := NewReplay(farm, rules)
+		for _, moves := range turns {
+			replay.ApplyTurn(moves)
+		}
+		var got []string
+		for _, v := range replay.Violations {
+			got = append(got, v.String())
+		}
+
+		if strings.Join(got, "; ") != strings.Join(want, "; ") {
+			t.Errorf("%s rules: replay found %v, verifier %v", rules.Name, got, want)
+		}
+	}
+}
+
+// TestReadInput tests that lem-in errors and invalid farms are reported
+func TestReadInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+		turns int
+	}{
+		{"transcript", "1\n##start\ns 0 0\n##end\ne 1 0\ns-e\n\nL1-e\n", "", 1},
+		{"lem-in error", "ERROR: invalid data format, no start room found\n", "no start room found", 0},
+		{"bad ant count", "x\n##start\ns 0 0\n##end\ne 1 0\ns-e\n", "invalid number of ants", 0},
+		{"unknown room", "1\n##start\ns 0 0\n##end\ne 1 0\ns-x\n", "unknown room", 0},
+		{"JSON trace", `{"ants":1,"start":"s","end":"e","rooms":[{"name":"s","x":0,"y":0},{"name":"e","x":1,"y":0}],
+			"links":[["s","e"]],"turns":1,"moves":[["L1-e"]]}`, "", 1},
+		{"bad JSON trace", `{"ants":1,`, "invalid JSON trace", 0},
+	}
+
+	for _, tt := range tests {
+		farm, turns, err := readInput(strings.NewReader(tt.input))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.err, err)
+			}
+			continue
+		}
+		if err != nil || farm == nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if len(turns) != tt.turns {
+			t.Errorf("%s: expected %d turns, got %d", tt.name, tt.turns, len(turns))
+		}
+	}
+
+	if farm, _, err := readInput(strings.NewReader("\n\n")); farm != nil || err != nil {
+		t.Errorf("Expected no farm and no error for empty input, got %v, %v", farm, err)
+	}
+}
+
+// TestReadInput_LongLines tests that a turn longer than bufio's default limit is read whole
+func TestReadInput_LongLines(t *testing.T) {
+	const ants = 20000
+	var farm, turn strings.Builder
+	fmt.Fprintf(&farm, "%d\n##start\ns 0 0\n##end\ne 1 0\ns-e\n\n", ants)
+	for id := 1; id <= ants; id++ {
+		fmt.Fprintf(&turn, "L%d-e ", id)
+	}
+
+	_, turns, err := readInput(strings.NewReader(farm.String() + turn.String() + "\n"))
+	if err != nil {
+		t.Fatalf("readInput returned error: %v", err)
+	}
+	if len(turns) != 1 || len(turns[0]) != ants {
+		t.Errorf("Expected one turn of %d moves, got %d turns", ants, len(turns))
+	}
+}
+
+// TestIsTerminal tests that output redirected to a file is not treated as a terminal
+func TestIsTerminal(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if isTerminal(file) {
+		t.Error("Expected a file not to be a terminal")
+	}
+}
+
+// TestMain_NoInteractiveNoColor tests that the output has no ANSI codes
+// without a terminal. It runs main in a child process, given the flags in
+// VISUALIZER_ARGS.
+func TestMain_NoInteractiveNoColor(t *testing.T) {
+	if args, ok := os.LookupEnv("VISUALIZER_ARGS"); ok {
+		os.Args = append([]string{"visualizer"}, strings.Fields(args)...)
+		main()
+		return
+	}
+
+	input := filepath.Join(t.TempDir(), "bad.txt")
+	transcript := "1\n##start\ns 0 0\na 1 0\n##end\ne 2 0\ns-a\na-e\n\nL1-x\nL1-a\nL1-e\n"
+	if err := os.WriteFile(input, []byte(transcript), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestMain_NoInteractiveNoColor$")
+	cmd.Env = append(os.Environ(), "VISUALIZER_ARGS=--no-interactive --heatmap "+input)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("visualizer failed: %v\n%s", err, out)
+	}
+
+	if strings.Contains(string(out), "\033") {
+		t.Errorf("Expected no ANSI codes, got:\n%q", out)
+	}
+	if !strings.Contains(string(out), "TURN 1: L1-x!") || !strings.Contains(string(out), "❌ L1-x: unknown room") {
+		t.Errorf("Expected the invalid move marked with '!' and explained, got:\n%s", out)
+	}
+}